@@ -79,11 +79,27 @@ func (c *Cli) Run(ctx context.Context, args []string) error {
 								Name:  "mirror",
 								Usage: "remote URL(only http/https) to mirror from",
 							},
+							&cli.StringSliceFlag{
+								Name:  "mirror-include",
+								Usage: "only mirror refs matching the pattern (e.g. refs/heads/*), can be repeated",
+							},
+							&cli.StringSliceFlag{
+								Name:  "mirror-exclude",
+								Usage: "don't mirror refs matching the pattern (e.g. refs/pull/*), can be repeated",
+							},
+							&cli.DurationFlag{
+								Name:  "mirror-interval",
+								Usage: "mirror sync interval, overrides mirror.interval for this repo",
+							},
 							&cli.StringFlag{
 								Name:    "description",
 								Usage:   "set repo description",
 								Aliases: []string{"desc"},
 							},
+							&cli.StringFlag{
+								Name:  "section",
+								Usage: "section the repo is listed under on the index page",
+							},
 							&cli.BoolFlag{
 								Name:  "private",
 								Usage: "make the repository private",
@@ -102,6 +118,20 @@ func (c *Cli) Run(ctx context.Context, args []string) error {
 							&cli.StringArg{Name: "name"},
 						},
 					},
+					{
+						Name:   "section",
+						Usage:  "get or set section the repo is listed under on the index page",
+						Action: c.repoSectionAction,
+						Arguments: []cli.Argument{
+							&cli.StringArg{Name: "name"},
+						},
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "unset",
+								Usage: "remove repo from its section",
+							},
+						},
+					},
 					{
 						Name:   "private",
 						Usage:  "toggle private status of a repo",
@@ -118,6 +148,28 @@ func (c *Cli) Run(ctx context.Context, args []string) error {
 							&cli.StringArg{Name: "name"},
 						},
 					},
+					{
+						Name:   "mirror",
+						Usage:  "turn existing repo into a mirror of remote URL(only http/https)",
+						Action: c.repoMirrorAction,
+						Arguments: []cli.Argument{
+							&cli.StringArg{Name: "name"},
+						},
+					},
+					{
+						Name:   "unmirror",
+						Usage:  "turn a mirror into a regular repo, that accepts pushes",
+						Action: c.repoUnmirrorAction,
+						Arguments: []cli.Argument{
+							&cli.StringArg{Name: "name"},
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "keep-remote",
+								Usage: "keep mirrored remote under provided name, instead of removing it",
+							},
+						},
+					},
 					{
 						Name:   "sync",
 						Usage:  "trigger sync for a mirror repository",
@@ -128,6 +180,68 @@ func (c *Cli) Run(ctx context.Context, args []string) error {
 					},
 				},
 			},
+			{
+				Name: "mirror",
+				Commands: []*cli.Command{
+					{
+						Name:   "import",
+						Usage:  "create mirrors of all repos of a forge org, or listed in a file",
+						Action: c.mirrorImportAction,
+						MutuallyExclusiveFlags: []cli.MutuallyExclusiveFlags{{
+							Required: true,
+							Flags: [][]cli.Flag{
+								{&cli.StringFlag{Name: "from-github-org", Usage: "import repos of github org"}},
+								{&cli.StringFlag{Name: "from-gitlab-group", Usage: "import repos of gitlab group, including subgroups"}},
+								{&cli.StringFlag{Name: "from-gitea-org", Usage: "import repos of gitea org"}},
+								{&cli.StringFlag{Name: "from-file", Usage: "import repos listed in file, one remote URL (and optional name) per line"}},
+							},
+						}},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "api-url",
+								Usage: "forge API URL, defaults to the one of configured forge of the same type",
+							},
+							&cli.StringSliceFlag{
+								Name:  "mirror-include",
+								Usage: "only mirror refs matching the pattern (e.g. refs/heads/*), can be repeated",
+							},
+							&cli.StringSliceFlag{
+								Name:  "mirror-exclude",
+								Usage: "don't mirror refs matching the pattern (e.g. refs/pull/*), can be repeated",
+							},
+							&cli.DurationFlag{
+								Name:  "mirror-interval",
+								Usage: "mirror sync interval, overrides mirror.interval for imported repos",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "release",
+				Commands: []*cli.Command{
+					{
+						Name:      "upload",
+						Usage:     "attach files to a tag, replacing assets with the same names",
+						ArgsUsage: "<name> <tag> <file>...",
+						Action:    c.releaseUploadAction,
+						Arguments: []cli.Argument{
+							&cli.StringArg{Name: "name"},
+							&cli.StringArg{Name: "tag"},
+						},
+					},
+					{
+						Name:      "delete",
+						Usage:     "delete assets of a tag",
+						ArgsUsage: "<name> <tag> <asset>...",
+						Action:    c.releaseDeleteAction,
+						Arguments: []cli.Argument{
+							&cli.StringArg{Name: "name"},
+							&cli.StringArg{Name: "tag"},
+						},
+					},
+				},
+			},
 			{
 				Name:        "shell",
 				Description: "git over sshd",
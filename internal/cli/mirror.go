@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/git"
+	"olexsmir.xyz/mugit/internal/mirror"
+)
+
+func (c *Cli) mirrorImportAction(ctx context.Context, cmd *cli.Command) error {
+	repos, err := c.importSource(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	// different upstreams can have the same name, e.g. in different orgs,
+	// the second one shouldn't be skipped as existing
+	remotes := make(map[string]string, len(repos))
+	for _, info := range repos {
+		name := git.ResolveName(info.Name)
+		if other, ok := remotes[name]; ok {
+			return fmt.Errorf("%s and %s would both be mirrored as %s", other, info.CloneURL, name)
+		}
+		remotes[name] = info.CloneURL
+	}
+
+	opts := newRepoOpts{
+		mirrorFilter: git.RefFilter{
+			Include: cmd.StringSlice("mirror-include"),
+			Exclude: cmd.StringSlice("mirror-exclude"),
+		},
+		mirrorInterval: cmd.Duration("mirror-interval"),
+	}
+
+	var created, skipped, failed int
+	for _, info := range repos {
+		name := git.ResolveName(info.Name)
+		opts.mirrorURL = info.CloneURL
+		opts.description = info.Description
+		opts.private = info.IsPrivate
+
+		err := c.createRepo(ctx, name, opts)
+		switch {
+		case errors.Is(err, errRepoExists):
+			slog.Info("skipping existing repo", "repo", name)
+			skipped++
+		case err != nil:
+			slog.Error("failed to import mirror", "repo", name, "remote", info.CloneURL, "err", err)
+			failed++
+		default:
+			slog.Info("imported mirror", "repo", name, "remote", info.CloneURL)
+			created++
+		}
+	}
+
+	slog.Info("mirror import completed", "created", created, "skipped", skipped, "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("failed to import %d of %d repos", failed, len(repos))
+	}
+	return nil
+}
+
+// importSource returns list of repos to import, from the only source set in flags.
+func (c *Cli) importSource(ctx context.Context, cmd *cli.Command) ([]mirror.RepoInfo, error) {
+	worker := mirror.NewWorker(c.cfg)
+	apiURL := cmd.String("api-url")
+
+	switch {
+	case cmd.IsSet("from-github-org"):
+		return worker.ListOrgRepos(ctx, config.ForgeGithub, apiURL, cmd.String("from-github-org"))
+
+	case cmd.IsSet("from-gitlab-group"):
+		return worker.ListOrgRepos(ctx, config.ForgeGitlab, apiURL, cmd.String("from-gitlab-group"))
+
+	case cmd.IsSet("from-gitea-org"):
+		return worker.ListOrgRepos(ctx, config.ForgeGitea, apiURL, cmd.String("from-gitea-org"))
+
+	case cmd.IsSet("from-file"):
+		f, err := os.Open(cmd.String("from-file"))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+
+		repos, err := mirror.ParseManifest(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+
+		// carry over metadata of repos hosted on known forges
+		for i, repo := range repos {
+			info, err := worker.LookupRepo(ctx, repo.CloneURL)
+			if errors.Is(err, mirror.ErrUnknownForge) {
+				continue
+			}
+			if err != nil {
+				slog.Warn("failed to get repo info from forge", "remote", repo.CloneURL, "err", err)
+				continue
+			}
+			repos[i].Description = info.Description
+			repos[i].IsPrivate = info.IsPrivate
+		}
+		return repos, nil
+
+	default:
+		return nil, fmt.Errorf("no import source provided")
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+
+	"olexsmir.xyz/mugit/internal/git"
+	"olexsmir.xyz/mugit/internal/release"
+)
+
+func (c *Cli) releaseUploadAction(ctx context.Context, cmd *cli.Command) error {
+	name, tag, files, err := c.getReleaseArgs(cmd)
+	if err != nil {
+		return err
+	}
+
+	store, err := c.openReleases(name, tag)
+	if err != nil {
+		return err
+	}
+
+	for _, fpath := range files {
+		f, err := os.Open(fpath)
+		if err != nil {
+			return err
+		}
+
+		asset, err := store.Add(tag, filepath.Base(fpath), f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", fpath, err)
+		}
+
+		slog.Info("uploaded release asset", "repo", name, "tag", tag, "asset", asset.Name, "sha256", asset.SHA256)
+	}
+	return nil
+}
+
+func (c *Cli) releaseDeleteAction(ctx context.Context, cmd *cli.Command) error {
+	name, tag, files, err := c.getReleaseArgs(cmd)
+	if err != nil {
+		return err
+	}
+
+	store, err := c.openReleases(name, tag)
+	if err != nil {
+		return err
+	}
+
+	for _, asset := range files {
+		if err := store.Remove(tag, asset); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", asset, err)
+		}
+		slog.Info("deleted release asset", "repo", name, "tag", tag, "asset", asset)
+	}
+	return nil
+}
+
+func (c *Cli) getReleaseArgs(cmd *cli.Command) (name, tag string, files []string, err error) {
+	name, err = c.getRepoNameArg(cmd)
+	if name == "" {
+		return "", "", nil, err
+	}
+
+	tag = cmd.StringArg("tag")
+	if tag == "" {
+		return "", "", nil, fmt.Errorf("no tag provided")
+	}
+
+	files = cmd.Args().Slice()
+	if len(files) == 0 {
+		return "", "", nil, fmt.Errorf("no files provided")
+	}
+	return name, tag, files, nil
+}
+
+// openReleases opens release store of the repo, assets can only be attached
+// to existing tags.
+func (c *Cli) openReleases(name, tag string) (*release.Store, error) {
+	path, err := git.ResolvePath(c.cfg.Repo.Dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.Open(path, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+
+	if _, err := repo.Tag(tag); err != nil {
+		return nil, err
+	}
+
+	return release.NewStore(path), nil
+}
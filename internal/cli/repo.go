@@ -2,9 +2,11 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/urfave/cli/v3"
 
@@ -12,24 +14,48 @@ import (
 	"olexsmir.xyz/mugit/internal/mirror"
 )
 
+var errRepoExists = errors.New("repository already exists")
+
+type newRepoOpts struct {
+	mirrorURL      string
+	mirrorFilter   git.RefFilter
+	mirrorInterval time.Duration
+	description    string
+	section        string
+	private        bool
+}
+
 func (c *Cli) repoNewAction(ctx context.Context, cmd *cli.Command) error {
 	name, err := c.getRepoNameArg(cmd)
 	if name == "" {
 		return err
 	}
 
+	return c.createRepo(ctx, name, newRepoOpts{
+		mirrorURL: cmd.String("mirror"),
+		mirrorFilter: git.RefFilter{
+			Include: cmd.StringSlice("mirror-include"),
+			Exclude: cmd.StringSlice("mirror-exclude"),
+		},
+		mirrorInterval: cmd.Duration("mirror-interval"),
+		description:    cmd.String("description"),
+		section:        cmd.String("section"),
+		private:        cmd.Bool("private"),
+	})
+}
+
+func (c *Cli) createRepo(ctx context.Context, name string, opts newRepoOpts) error {
 	path, err := git.ResolvePath(c.cfg.Repo.Dir, name)
 	if err != nil {
 		return err
 	}
 
 	if _, err = os.Stat(path); err == nil {
-		return fmt.Errorf("repository already exists: %s", name)
+		return fmt.Errorf("%w: %s", errRepoExists, name)
 	}
 
-	mirrorURL := cmd.String("mirror")
-	if mirrorURL != "" {
-		if merr := mirror.IsRemoteSupported(mirrorURL); merr != nil {
+	if opts.mirrorURL != "" {
+		if merr := mirror.IsRemoteSupported(opts.mirrorURL); merr != nil {
 			return merr
 		}
 	}
@@ -43,15 +69,25 @@ func (c *Cli) repoNewAction(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to open repo: %w", err)
 	}
 
-	if err := repo.SetPrivate(cmd.Bool("private")); err != nil {
+	if err := repo.SetPrivate(opts.private); err != nil {
 		return fmt.Errorf("failed to set private status: %w", err)
 	}
 
-	if mirrorURL != "" {
-		if err := repo.SetMirrorRemote(mirrorURL); err != nil {
+	if opts.mirrorURL != "" {
+		if err := repo.SetMirrorRemote(opts.mirrorURL); err != nil {
 			return fmt.Errorf("failed to set mirror remote: %w", err)
 		}
 
+		if err := repo.SetMirrorRefFilter(opts.mirrorFilter); err != nil {
+			return fmt.Errorf("failed to set mirror refs filter: %w", err)
+		}
+
+		if opts.mirrorInterval > 0 {
+			if err := repo.SetMirrorInterval(opts.mirrorInterval); err != nil {
+				return fmt.Errorf("failed to set mirror interval: %w", err)
+			}
+		}
+
 		slog.Info("performing initial sync for mirror", "repo", name)
 		if err := c.syncRepo(ctx, name); err != nil {
 			return err
@@ -59,13 +95,18 @@ func (c *Cli) repoNewAction(ctx context.Context, cmd *cli.Command) error {
 		slog.Info("initial mirror sync completed", "repo", name)
 	}
 
-	desc := cmd.String("description")
-	if desc != "" {
-		if err := repo.SetDescription(desc); err != nil {
+	if opts.description != "" {
+		if err := repo.SetDescription(opts.description); err != nil {
 			return fmt.Errorf("failed to set description: %w", err)
 		}
 	}
 
+	if opts.section != "" {
+		if err := repo.SetSection(opts.section); err != nil {
+			return fmt.Errorf("failed to set section: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -96,6 +137,32 @@ func (c *Cli) repoDescriptionAction(ctx context.Context, cmd *cli.Command) error
 	return nil
 }
 
+func (c *Cli) repoSectionAction(ctx context.Context, cmd *cli.Command) error {
+	name, err := c.getRepoNameArg(cmd)
+	if name == "" {
+		return err
+	}
+
+	repo, err := c.openRepo(name)
+	if err != nil {
+		return fmt.Errorf("failed to open repo: %w", err)
+	}
+
+	if newSection := cmd.Args().Get(0); newSection != "" || cmd.Bool("unset") {
+		if err = repo.SetSection(newSection); err != nil {
+			return fmt.Errorf("failed to set section: %w", err)
+		}
+	}
+
+	section, err := repo.Section()
+	if err != nil {
+		return fmt.Errorf("failed to get section: %w", err)
+	}
+
+	slog.Info("repo section", "repo", name, "section", section)
+	return nil
+}
+
 func (c *Cli) repoPrivateAction(ctx context.Context, cmd *cli.Command) error {
 	name, err := c.getRepoNameArg(cmd)
 	if name == "" {
@@ -141,6 +208,65 @@ func (c *Cli) repoDefaultAction(ctx context.Context, cmd *cli.Command) error {
 	return err
 }
 
+func (c *Cli) repoMirrorAction(ctx context.Context, cmd *cli.Command) error {
+	name, err := c.getRepoNameArg(cmd)
+	if name == "" {
+		return err
+	}
+
+	mirrorURL := cmd.Args().Get(0)
+	if mirrorURL == "" {
+		return fmt.Errorf("no remote url provided")
+	}
+	if merr := mirror.IsRemoteSupported(mirrorURL); merr != nil {
+		return merr
+	}
+
+	repo, err := c.openRepo(name)
+	if err != nil {
+		return fmt.Errorf("failed to open repo: %w", err)
+	}
+
+	if isMirror, _ := repo.IsMirror(); isMirror {
+		return fmt.Errorf("repository is already a mirror: %s", name)
+	}
+
+	if err := repo.SetMirrorRemote(mirrorURL); err != nil {
+		return fmt.Errorf("failed to set mirror remote: %w", err)
+	}
+
+	if err := c.syncRepo(ctx, name); err != nil {
+		// don't leave a mirror that was never fetched
+		if uerr := repo.UnsetMirror(""); uerr != nil {
+			return errors.Join(err, fmt.Errorf("failed to undo mirror remote: %w", uerr))
+		}
+		return err
+	}
+
+	slog.Info("repo is now a mirror", "repo", name, "remote", mirrorURL)
+	return nil
+}
+
+func (c *Cli) repoUnmirrorAction(ctx context.Context, cmd *cli.Command) error {
+	name, err := c.getRepoNameArg(cmd)
+	if name == "" {
+		return err
+	}
+
+	repo, err := c.openRepo(name)
+	if err != nil {
+		return fmt.Errorf("failed to open repo: %w", err)
+	}
+
+	keepAs := cmd.String("keep-remote")
+	if err := repo.UnsetMirror(keepAs); err != nil {
+		return fmt.Errorf("failed to unmirror: %w", err)
+	}
+
+	slog.Info("repo is no longer a mirror", "repo", name, "kept_remote", keepAs)
+	return nil
+}
+
 func (c *Cli) repoSyncAction(ctx context.Context, cmd *cli.Command) error {
 	name, err := c.getRepoNameArg(cmd)
 	if name == "" {
@@ -8,18 +8,37 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
 
 	"github.com/urfave/cli/v3"
 
 	"olexsmir.xyz/mugit/internal/handlers"
 	"olexsmir.xyz/mugit/internal/mirror"
+	"olexsmir.xyz/mugit/internal/search"
 )
 
 func (c *Cli) serveAction(ctx context.Context, cmd *cli.Command) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// background workers, waited for on shutdown
+	var wg sync.WaitGroup
+
+	var index *search.Index
+	if c.cfg.Search.Enable {
+		index = search.NewIndex(c.cfg)
+		wg.Go(func() {
+			slog.Info("starting search indexer")
+			if err := index.Start(ctx); err != nil {
+				slog.Error("failed to start search indexer", "err", err)
+			}
+		})
+	}
+
 	httpServer := &http.Server{
 		Addr:    net.JoinHostPort(c.cfg.Server.Host, strconv.Itoa(c.cfg.Server.Port)),
-		Handler: handlers.InitRoutes(c.cfg),
+		Handler: handlers.InitRoutes(c.cfg, index),
 	}
 	go func() {
 		slog.Info("starting http server", "host", c.cfg.Server.Host, "port", c.cfg.Server.Port)
@@ -30,12 +49,15 @@ func (c *Cli) serveAction(ctx context.Context, cmd *cli.Command) error {
 
 	if c.cfg.Mirror.Enable {
 		mirrorer := mirror.NewWorker(c.cfg)
-		go func() {
+		if index != nil {
+			mirrorer.OnUpdate(index.Trigger)
+		}
+		wg.Go(func() {
 			slog.Info("starting mirroring worker")
 			if err := mirrorer.Start(ctx); err != nil {
 				slog.Error("failed to start mirrorer", "err", err)
 			}
-		}()
+		})
 	}
 
 	sigChan := make(chan os.Signal, 1)
@@ -43,12 +65,16 @@ func (c *Cli) serveAction(ctx context.Context, cmd *cli.Command) error {
 
 	sig := <-sigChan
 	slog.Info("received signal, starting graceful shutdown", "signal", sig)
+	cancel()
 
-	if err := httpServer.Shutdown(ctx); err != nil {
+	if err := httpServer.Shutdown(context.Background()); err != nil {
 		slog.Error("HTTP server shutdown error", "err", err)
 	} else {
 		slog.Info("HTTP server shutdown complete")
 	}
 
+	wg.Wait()
+	slog.Info("background workers stopped")
+
 	return nil
 }
@@ -18,8 +18,20 @@ var (
 )
 
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host    string        `yaml:"host"`
+	Port    int           `yaml:"port"`
+	Headers HeadersConfig `yaml:"headers"`
+}
+
+// HeadersConfig is security headers set on every response. Empty values
+// are set to defaults, except for HSTS, which is off unless set.
+type HeadersConfig struct {
+	CSP               string `yaml:"csp"`
+	RawCSP            string `yaml:"raw_csp"` // for raw files, which could be html or svg
+	FrameOptions      string `yaml:"frame_options"`
+	ReferrerPolicy    string `yaml:"referrer_policy"`
+	PermissionsPolicy string `yaml:"permissions_policy"`
+	HSTS              string `yaml:"hsts"` // e.g. max-age=31536000; includeSubDomains
 }
 
 type MetaConfig struct {
@@ -30,8 +42,9 @@ type MetaConfig struct {
 }
 
 type RepoConfig struct {
-	Dir     string   `yaml:"dir"`
-	Readmes []string `yaml:"readmes"`
+	Dir      string   `yaml:"dir"`
+	Readmes  []string `yaml:"readmes"`
+	HideRefs []string `yaml:"hide_refs"`
 }
 
 type SSHConfig struct {
@@ -42,9 +55,62 @@ type SSHConfig struct {
 }
 
 type MirrorConfig struct {
-	Enable      bool          `yaml:"enable"`
-	Interval    time.Duration `yaml:"interval"`
-	GithubToken string        `yaml:"github_token"`
+	Enable          bool          `yaml:"enable"`
+	Interval        time.Duration `yaml:"interval"`
+	FetchTimeout    time.Duration `yaml:"fetch_timeout"`
+	GithubToken     string        `yaml:"github_token"`
+	SyncDescription bool          `yaml:"sync_description"`
+	Forges          []ForgeConfig `yaml:"forges"`
+}
+
+// ForgeConfig describes API of a forge hosting mirrored repos.
+type ForgeConfig struct {
+	Host   string `yaml:"host"`    // host of the remote urls, e.g. github.com
+	Type   string `yaml:"type"`    // github, gitlab or gitea
+	APIURL string `yaml:"api_url"` // e.g. https://api.github.com
+}
+
+const (
+	ForgeGithub = "github"
+	ForgeGitlab = "gitlab"
+	ForgeGitea  = "gitea"
+)
+
+type SearchConfig struct {
+	Enable   bool          `yaml:"enable"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+type MarkupConfig struct {
+	Sanitize  string           `yaml:"sanitize"` // strict or relaxed
+	Timeout   time.Duration    `yaml:"timeout"`
+	Renderers []RendererConfig `yaml:"renderers"`
+}
+
+const (
+	SanitizeStrict  = "strict"
+	SanitizeRelaxed = "relaxed"
+)
+
+// RendererConfig describes external command that renders markup files. The
+// command reads file from stdin, and writes html to stdout.
+type RendererConfig struct {
+	Extensions []string `yaml:"extensions"` // e.g. .adoc
+	Filenames  []string `yaml:"filenames"`  // e.g. README.org
+	Command    []string `yaml:"command"`    // e.g. [pandoc, -f, org, -t, html]
+}
+
+// DiffConfig limits size of diffs shown in commit and compare views, files
+// over the limits are shown with link to the raw file instead.
+type DiffConfig struct {
+	MaxFileLines int `yaml:"max_file_lines"`
+	MaxFileBytes int `yaml:"max_file_bytes"`
+	MaxLines     int `yaml:"max_lines"` // of all files
+	MaxBytes     int `yaml:"max_bytes"`
+
+	// Similarity is minimal similarity, in percents, of files to be shown
+	// as renamed or copied.
+	Similarity int `yaml:"similarity"`
 }
 
 type CacheConfig struct {
@@ -59,6 +125,9 @@ type Config struct {
 	Repo   RepoConfig   `yaml:"repo"`
 	SSH    SSHConfig    `yaml:"ssh"`
 	Mirror MirrorConfig `yaml:"mirror"`
+	Search SearchConfig `yaml:"search"`
+	Markup MarkupConfig `yaml:"markup"`
+	Diff   DiffConfig   `yaml:"diff"`
 	Cache  CacheConfig  `yaml:"cache"`
 }
 
@@ -121,6 +190,23 @@ func (c *Config) ensureDefaults() {
 	if c.Server.Port == 0 {
 		c.Server.Port = 8080
 	}
+	if c.Server.Headers.CSP == "" {
+		// ui works without javascript, images in READMEs can come from anywhere
+		c.Server.Headers.CSP = "default-src 'none'; style-src 'self'; img-src 'self' https: data:; " +
+			"form-action 'self'; frame-ancestors 'none'; base-uri 'none'"
+	}
+	if c.Server.Headers.RawCSP == "" {
+		c.Server.Headers.RawCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src 'self' data:; sandbox"
+	}
+	if c.Server.Headers.FrameOptions == "" {
+		c.Server.Headers.FrameOptions = "DENY"
+	}
+	if c.Server.Headers.ReferrerPolicy == "" {
+		c.Server.Headers.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+	if c.Server.Headers.PermissionsPolicy == "" {
+		c.Server.Headers.PermissionsPolicy = "camera=(), microphone=(), geolocation=()"
+	}
 
 	// meta
 	if c.Meta.Title == "" {
@@ -132,6 +218,7 @@ func (c *Config) ensureDefaults() {
 		c.Repo.Readmes = []string{
 			"README.md", "readme.md",
 			"README.html", "readme.html",
+			"README.adoc", "README.org", "README.rst",
 			"README.txt", "readme.txt",
 			"readme",
 		}
@@ -149,6 +236,46 @@ func (c *Config) ensureDefaults() {
 	if c.Mirror.Interval == 0 {
 		c.Mirror.Interval = 8 * time.Hour
 	}
+	if c.Mirror.FetchTimeout == 0 {
+		c.Mirror.FetchTimeout = time.Hour
+	}
+	if len(c.Mirror.Forges) == 0 {
+		c.Mirror.Forges = []ForgeConfig{
+			{Host: "github.com", Type: ForgeGithub, APIURL: "https://api.github.com"},
+			{Host: "gitlab.com", Type: ForgeGitlab, APIURL: "https://gitlab.com/api/v4"},
+			{Host: "codeberg.org", Type: ForgeGitea, APIURL: "https://codeberg.org/api/v1"},
+		}
+	}
+
+	// search
+	if c.Search.Interval == 0 {
+		c.Search.Interval = time.Minute
+	}
+
+	// markup
+	if c.Markup.Sanitize == "" {
+		c.Markup.Sanitize = SanitizeRelaxed
+	}
+	if c.Markup.Timeout == 0 {
+		c.Markup.Timeout = 5 * time.Second
+	}
+
+	// diff
+	if c.Diff.MaxFileLines == 0 {
+		c.Diff.MaxFileLines = 2000
+	}
+	if c.Diff.MaxFileBytes == 0 {
+		c.Diff.MaxFileBytes = 256 << 10
+	}
+	if c.Diff.MaxLines == 0 {
+		c.Diff.MaxLines = 20000
+	}
+	if c.Diff.MaxBytes == 0 {
+		c.Diff.MaxBytes = 2 << 20
+	}
+	if c.Diff.Similarity == 0 {
+		c.Diff.Similarity = 50
+	}
 
 	// cache
 	if c.Cache.HomePage == 0 {
@@ -34,6 +34,56 @@ func (c Config) validate() error {
 		}
 	}
 
+	if c.Mirror.Enable && c.Mirror.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("mirror.interval must be positive"))
+	}
+
+	if c.Mirror.Enable && c.Mirror.FetchTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("mirror.fetch_timeout must be positive"))
+	}
+
+	if c.Search.Enable && c.Search.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("search.interval must be positive"))
+	}
+
+	switch c.Markup.Sanitize {
+	case "", SanitizeStrict, SanitizeRelaxed:
+	default:
+		errs = append(errs, fmt.Errorf("markup.sanitize must be one of strict, relaxed"))
+	}
+
+	if len(c.Markup.Renderers) > 0 && c.Markup.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("markup.timeout must be positive"))
+	}
+
+	for i, r := range c.Markup.Renderers {
+		if len(r.Command) == 0 || r.Command[0] == "" {
+			errs = append(errs, fmt.Errorf("markup.renderers[%d].command is required", i))
+		}
+		if len(r.Extensions) == 0 && len(r.Filenames) == 0 {
+			errs = append(errs, fmt.Errorf("markup.renderers[%d]: extensions or filenames are required", i))
+		}
+	}
+
+	if c.Diff.MaxFileLines < 0 || c.Diff.MaxFileBytes < 0 || c.Diff.MaxLines < 0 || c.Diff.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("diff limits must be positive"))
+	}
+
+	if c.Diff.Similarity < 0 || c.Diff.Similarity > 100 {
+		errs = append(errs, fmt.Errorf("diff.similarity must be between 0 and 100"))
+	}
+
+	for i, f := range c.Mirror.Forges {
+		if f.Host == "" || f.APIURL == "" {
+			errs = append(errs, fmt.Errorf("mirror.forges[%d]: host and api_url are required", i))
+		}
+		switch f.Type {
+		case ForgeGithub, ForgeGitlab, ForgeGitea:
+		default:
+			errs = append(errs, fmt.Errorf("mirror.forges[%d].type must be one of github, gitlab, gitea", i))
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"olexsmir.xyz/x/is"
 )
@@ -69,6 +70,93 @@ func TestConfig_Validate(t *testing.T) {
 				Server: ServerConfig{Port: -1},
 			},
 		},
+		{
+			name:     "negative mirror interval",
+			expected: "mirror.interval must be positive",
+			c: Config{
+				Meta:   MetaConfig{Host: "example.com"},
+				Repo:   RepoConfig{Dir: t.TempDir()},
+				Mirror: MirrorConfig{Enable: true, Interval: -time.Hour},
+			},
+		},
+		{
+			name:     "negative mirror fetch timeout",
+			expected: "mirror.fetch_timeout must be positive",
+			c: Config{
+				Meta:   MetaConfig{Host: "example.com"},
+				Repo:   RepoConfig{Dir: t.TempDir()},
+				Mirror: MirrorConfig{Enable: true, FetchTimeout: -time.Minute},
+			},
+		},
+		{
+			name:     "negative search interval",
+			expected: "search.interval must be positive",
+			c: Config{
+				Meta:   MetaConfig{Host: "example.com"},
+				Repo:   RepoConfig{Dir: t.TempDir()},
+				Search: SearchConfig{Enable: true, Interval: -time.Minute},
+			},
+		},
+		{
+			name:     "unknown markup sanitize policy",
+			expected: "markup.sanitize must be one of strict, relaxed",
+			c: Config{
+				Meta:   MetaConfig{Host: "example.com"},
+				Repo:   RepoConfig{Dir: t.TempDir()},
+				Markup: MarkupConfig{Sanitize: "none"},
+			},
+		},
+		{
+			name:     "markup renderer without command",
+			expected: "markup.renderers[0].command is required",
+			c: Config{
+				Meta: MetaConfig{Host: "example.com"},
+				Repo: RepoConfig{Dir: t.TempDir()},
+				Markup: MarkupConfig{Timeout: time.Second, Renderers: []RendererConfig{
+					{Extensions: []string{".adoc"}},
+				}},
+			},
+		},
+		{
+			name:     "markup renderer without extensions",
+			expected: "markup.renderers[0]: extensions or filenames are required",
+			c: Config{
+				Meta: MetaConfig{Host: "example.com"},
+				Repo: RepoConfig{Dir: t.TempDir()},
+				Markup: MarkupConfig{Timeout: time.Second, Renderers: []RendererConfig{
+					{Command: []string{"asciidoctor"}},
+				}},
+			},
+		},
+		{
+			name:     "negative diff limit",
+			expected: "diff limits must be positive",
+			c: Config{
+				Meta: MetaConfig{Host: "example.com"},
+				Repo: RepoConfig{Dir: t.TempDir()},
+				Diff: DiffConfig{MaxFileLines: -1},
+			},
+		},
+		{
+			name:     "diff similarity over 100",
+			expected: "diff.similarity must be between 0 and 100",
+			c: Config{
+				Meta: MetaConfig{Host: "example.com"},
+				Repo: RepoConfig{Dir: t.TempDir()},
+				Diff: DiffConfig{Similarity: 120},
+			},
+		},
+		{
+			name:     "unknown forge type",
+			expected: "mirror.forges[0].type must be one of",
+			c: Config{
+				Meta: MetaConfig{Host: "example.com"},
+				Repo: RepoConfig{Dir: t.TempDir()},
+				Mirror: MirrorConfig{Forges: []ForgeConfig{
+					{Host: "git.example.com", Type: "sourcehut", APIURL: "https://git.example.com/api"},
+				}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"path"
+	"strings"
+)
+
+// Attributes is the content of root .gitattributes of the repo.
+type Attributes string
+
+// Attributes returns root .gitattributes, it's empty if there's none.
+func (g *Repo) Attributes() Attributes {
+	fc, err := g.FileContent(".gitattributes")
+	if err != nil {
+		return ""
+	}
+	return Attributes(fc.String())
+}
+
+// LinguistLanguage returns language of the file set with linguist-language
+// attribute, or empty string if it isn't set.
+func (a Attributes) LinguistLanguage(filePath string) string {
+	return a.Value(filePath, "linguist-language")
+}
+
+// Generated reports whether the file is marked with linguist-generated.
+func (a Attributes) Generated(filePath string) bool {
+	state, value := a.lookup(filePath, "linguist-generated")
+	return state == attrSet || (state == attrValue && value == "true")
+}
+
+// NoDiff reports whether the file has diff unset, or is marked as binary.
+func (a Attributes) NoDiff(filePath string) bool {
+	if state, _ := a.lookup(filePath, "diff"); state == attrUnset {
+		return true
+	}
+	state, _ := a.lookup(filePath, "binary")
+	return state == attrSet
+}
+
+// Value returns value of attr for the file, last matching line wins, same as in git.
+func (a Attributes) Value(filePath, attr string) string {
+	if state, value := a.lookup(filePath, attr); state == attrValue {
+		return value
+	}
+	return ""
+}
+
+type attrState int
+
+const (
+	attrUnspecified attrState = iota
+	attrSet                   // attr
+	attrUnset                 // -attr
+	attrValue                 // attr=value
+)
+
+func (a Attributes) lookup(filePath, attr string) (attrState, string) {
+	state, value := attrUnspecified, ""
+	for line := range strings.Lines(string(a)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if !matchAttrPattern(fields[0], filePath) {
+			continue
+		}
+
+		for _, f := range fields[1:] {
+			switch {
+			case strings.HasPrefix(f, attr+"="):
+				state, value = attrValue, f[len(attr)+1:]
+			case f == attr:
+				state, value = attrSet, ""
+			case f == "-"+attr:
+				state, value = attrUnset, ""
+			case f == "!"+attr:
+				state, value = attrUnspecified, ""
+			}
+		}
+	}
+	return state, value
+}
+
+// matchAttrPattern matches file against a .gitattributes pattern. Patterns
+// without a slash match the file name at any depth, others are relative to
+// the repo root.
+func matchAttrPattern(pattern, filePath string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(filePath))
+		return ok
+	}
+
+	pattern = strings.TrimPrefix(pattern, "/")
+	if rest, ok := strings.CutPrefix(pattern, "**/"); ok {
+		parts := strings.Split(filePath, "/")
+		for i := range parts {
+			if ok, _ := path.Match(rest, strings.Join(parts[i:], "/")); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	ok, _ := path.Match(pattern, filePath)
+	return ok
+}
@@ -0,0 +1,70 @@
+package git
+
+import (
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestAttributes_Value(t *testing.T) {
+	attrs := Attributes(`
+# comment
+*.inc linguist-language=PHP
+/scripts/* linguist-language=Bash
+**/templates/*.html linguist-language=Go-HTML-Template
+vendor/*.inc -linguist-language
+*.md text
+`)
+	tests := []struct {
+		path, want string
+	}{
+		{"index.inc", "PHP"},
+		{"lib/deep/file.inc", "PHP"},
+		{"vendor/file.inc", ""},
+		{"scripts/build", "Bash"},
+		{"other/scripts/build", ""},
+		{"web/templates/index.html", "Go-HTML-Template"},
+		{"README.md", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			is.Equal(t, attrs.Value(tt.path, "linguist-language"), tt.want)
+		})
+	}
+}
+
+func TestAttributes_Collapse(t *testing.T) {
+	attrs := Attributes(`
+gen/** linguist-generated
+*.pb.go linguist-generated=true
+docs/*.pb.go -linguist-generated
+*.svg -diff
+*.bin binary
+`)
+	tests := []struct {
+		path              string
+		generated, noDiff bool
+	}{
+		{"gen/api.go", true, false},
+		{"proto/user.pb.go", true, false},
+		{"docs/user.pb.go", false, false},
+		{"logo.svg", false, true},
+		{"data/blob.bin", false, true},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			is.Equal(t, attrs.Generated(tt.path), tt.generated)
+			is.Equal(t, attrs.NoDiff(tt.path), tt.noDiff)
+		})
+	}
+}
+
+func TestRepo_Attributes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile(".gitattributes", "*.conf linguist-language=Nginx\n", "Add attributes")
+
+	attrs := r.open().Attributes()
+	is.Equal(t, attrs.LinguistLanguage("site.conf"), "Nginx")
+	is.Equal(t, attrs.LinguistLanguage("main.go"), "")
+}
@@ -0,0 +1,148 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blameTimeout is max duration of git blame, it's slow on files with long history.
+const blameTimeout = 10 * time.Second
+
+type BlameLine struct {
+	Number  int
+	Content string
+}
+
+// BlameRun is a group of consecutive lines last changed by the same commit.
+type BlameRun struct {
+	Commit *Commit // only has hashes, author, committer, and summary as message
+	Lines  []BlameLine
+
+	// Previous is the parent commit the lines were blamed through,
+	// with path of the file in it. Empty if lines were added by a root commit.
+	Previous     string
+	PreviousPath string
+}
+
+type blameCommit struct {
+	commit       Commit
+	previous     string
+	previousPath string
+}
+
+func (g *Repo) Blame(ctx context.Context, fpath string) ([]BlameRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, blameTimeout)
+	defer cancel()
+
+	output, err := g.streamingGit(ctx, "blame", "--porcelain", g.h.String(), "--", path.Clean(fpath))
+	if err != nil {
+		return nil, fmt.Errorf("blame %q: %w", fpath, err)
+	}
+
+	runs, err := parseBlame(output)
+	if cerr := output.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("blame %q: %w", fpath, ctx.Err())
+		}
+		return nil, fmt.Errorf("blame %q: %w", fpath, err)
+	}
+	return runs, nil
+}
+
+// parseBlame parses output of git blame --porcelain. Commit info is only
+// printed the first time the commit is seen, so it's remembered by hash.
+func parseBlame(r io.Reader) ([]BlameRun, error) {
+	commits := make(map[string]*blameCommit)
+
+	var runs []BlameRun
+	var current *blameCommit
+	var lineNumber int
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+
+		// content line ends an entry
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			if current == nil {
+				return nil, fmt.Errorf("unexpected content line %q", line)
+			}
+
+			bl := BlameLine{Number: lineNumber, Content: content}
+			if n := len(runs); n > 0 && runs[n-1].Commit.Hash == current.commit.Hash {
+				runs[n-1].Lines = append(runs[n-1].Lines, bl)
+			} else {
+				runs = append(runs, BlameRun{
+					Commit:       &current.commit,
+					Lines:        []BlameLine{bl},
+					Previous:     current.previous,
+					PreviousPath: current.previousPath,
+				})
+			}
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		if current == nil {
+			// header: <hash> <orig line> <final line> [<lines in group>]
+			fields := strings.Fields(value)
+			if len(key) < 40 || len(fields) < 2 {
+				return nil, fmt.Errorf("unexpected header line %q", line)
+			}
+
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("parsing line number of %q: %w", line, err)
+			}
+			lineNumber = n
+
+			current = commits[key]
+			if current == nil {
+				current = &blameCommit{commit: Commit{Hash: key, HashShort: key[:7]}}
+				commits[key] = current
+			}
+			continue
+		}
+
+		c := &current.commit
+		switch key {
+		case "author":
+			c.AuthorName = value
+		case "author-mail":
+			c.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			c.Authored = parseUnix(value, c.Authored)
+		case "committer":
+			c.CommitterName = value
+		case "committer-mail":
+			c.CommitterEmail = strings.Trim(value, "<>")
+		case "committer-time":
+			c.Committed = parseUnix(value, c.Committed)
+		case "summary":
+			c.Message = value
+		case "previous":
+			current.previous, current.previousPath, _ = strings.Cut(value, " ")
+		}
+	}
+
+	return runs, sc.Err()
+}
+
+func parseUnix(value string, fallback time.Time) time.Time {
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestRepo_Blame(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commitFile("main.go", "a\nb\nc\nd\n", "Initial commit")
+	second := r.commitFile("main.go", "a\nB\nC\nd\n", "Change middle")
+
+	runs, err := r.open().Blame(t.Context(), "main.go")
+	is.Err(t, err, nil)
+	is.Equal(t, len(runs), 3)
+
+	is.Equal(t, runs[0].Commit.Hash, first.String())
+	is.Equal(t, runs[0].Commit.Message, "Initial commit")
+	is.Equal(t, runs[0].Commit.AuthorEmail, "test@test.local")
+	is.Equal(t, runs[0].Lines, []BlameLine{{Number: 1, Content: "a"}})
+	is.Equal(t, runs[0].Previous, "")
+
+	is.Equal(t, runs[1].Commit.Hash, second.String())
+	is.Equal(t, runs[1].Lines, []BlameLine{{2, "B"}, {3, "C"}})
+	is.Equal(t, runs[1].Previous, first.String())
+	is.Equal(t, runs[1].PreviousPath, "main.go")
+
+	// same commit is shared between runs
+	is.Equal(t, runs[2].Commit == runs[0].Commit, true)
+	is.Equal(t, runs[2].Lines, []BlameLine{{4, "d"}})
+}
+
+func TestRepo_BlameNotFound(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("main.go", "a\n", "Initial commit")
+
+	_, err := r.open().Blame(t.Context(), "nonexistent.go")
+	is.Err(t, err, "exit status")
+}
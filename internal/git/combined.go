@@ -0,0 +1,76 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// parseCombined parses combined diff of a single file, as printed by
+// git diff-tree --cc, which go-gitdiff doesn't support.
+func parseCombined(data []byte) (Diff, error) {
+	diff := Diff{Combined: true}
+	var name string
+	var cols int
+	for line := range bytes.Lines(data) {
+		s := strings.TrimSuffix(string(line), "\n")
+		inHunk := len(diff.TextFragments) > 0
+
+		switch {
+		case !inHunk && strings.HasPrefix(s, "diff --cc "):
+			name = unquoteName(s[len("diff --cc "):])
+		case !inHunk && strings.HasPrefix(s, "new file mode"):
+			diff.IsNew = true
+		case !inHunk && strings.HasPrefix(s, "deleted file mode"):
+			diff.IsDelete = true
+		case !inHunk && strings.HasPrefix(s, "Binary files"):
+			diff.IsBinary = true
+
+		case strings.HasPrefix(s, "@@"):
+			// @@@ -1,3 -1,3 +1,4 @@@, new file range is the last one
+			cols = len(s) - len(strings.TrimLeft(s, "@")) - 1
+			fields := strings.Fields(s)
+			if len(fields) < cols+2 {
+				return Diff{}, fmt.Errorf("parsing combined diff: invalid hunk header %q", s)
+			}
+			start, _, _ := strings.Cut(strings.TrimPrefix(fields[cols+1], "+"), ",")
+			pos, err := strconv.ParseInt(start, 10, 64)
+			if err != nil {
+				return Diff{}, fmt.Errorf("parsing combined diff: invalid hunk header %q", s)
+			}
+			diff.TextFragments = append(diff.TextFragments, TextFragment{Header: s, NewPosition: pos})
+
+		case inHunk && len(line) > cols && line[0] != '\\':
+			tf := &diff.TextFragments[len(diff.TextFragments)-1]
+			ops := s[:cols]
+			op := gitdiff.OpContext
+			switch {
+			case strings.Contains(ops, "+"):
+				op = gitdiff.OpAdd
+			case strings.Contains(ops, "-"):
+				op = gitdiff.OpDelete
+			}
+			tf.Lines = append(tf.Lines, gitdiff.Line{Op: op, Line: string(line[cols:])})
+			tf.Ops = append(tf.Ops, ops)
+		}
+	}
+
+	if diff.IsDelete {
+		diff.Name.Old = name
+	} else {
+		diff.Name.New = name
+	}
+	return diff, nil
+}
+
+// unquoteName unquotes file name, that git quotes if it has special
+// characters.
+func unquoteName(name string) string {
+	if s, err := strconv.Unquote(name); err == nil && strings.HasPrefix(name, `"`) {
+		return s
+	}
+	return name
+}
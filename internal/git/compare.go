@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -9,38 +10,68 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
+// Range is a range of commits to compare. Same as in git diff, changes of
+// two-dot range are taken between base and head, and of three-dot range,
+// between merge base of base and head, and head.
+type Range struct {
+	Base, Head string
+	TwoDot     bool
+}
+
+// ParseRange parses base...head, or base..head range.
+func ParseRange(s string) (Range, bool) {
+	// refs can't contain "..", so the first one is the separator
+	i := strings.Index(s, "..")
+	if i < 0 {
+		return Range{}, false
+	}
+
+	rng := Range{Base: s[:i], Head: s[i+2:], TwoDot: true}
+	if head, ok := strings.CutPrefix(rng.Head, "."); ok {
+		rng.Head, rng.TwoDot = head, false
+	}
+	return rng, rng.Base != "" && rng.Head != ""
+}
+
+func (r Range) String() string {
+	if r.TwoDot {
+		return r.Base + ".." + r.Head
+	}
+	return r.Base + "..." + r.Head
+}
+
 type Compare struct {
 	BaseRef, BaseHash string
 	HeadRef, HeadHash string
-	MergeBase         string
+	TwoDot            bool
+	MergeBase         string // empty if histories of two-dot range are unrelated
+	DiffBase          string // hash changes are taken from, MergeBase, or BaseHash
 	Ahead             int
 	Behind            int
-	Commits           []*Commit
+	Commits           []*Commit // in head, but not in base
 	Diff              *NiceDiff
 }
 
-func (g *Repo) Compare(baseRef, headRef string) (*Compare, error) {
+func (g *Repo) Compare(ctx context.Context, rng Range, opts DiffOptions) (*Compare, error) {
+	baseRef, headRef := rng.Base, rng.Head
 	if baseRef == "" || headRef == "" {
 		return nil, errors.New("base and head refs can not be empty")
 	}
 
-	baseHash, err := g.resolveRef(baseRef)
-	if err != nil {
-		return nil, fmt.Errorf("resolving base ref %q: %w", baseRef, err)
-	}
-
-	headHash, err := g.resolveRef(headRef)
+	baseHash, headHash, err := g.resolveRange(baseRef, headRef)
 	if err != nil {
-		return nil, fmt.Errorf("resolving head ref %q: %w", headRef, err)
+		return nil, err
 	}
 
+	var mergeBase string
 	mergeBaseOut, err := g.mergeBase(baseHash.String(), headHash.String())
-	if err != nil {
+	switch {
+	case err == nil:
+		mergeBase = strings.TrimSpace(string(mergeBaseOut))
+	case !rng.TwoDot:
 		return nil, fmt.Errorf("merge-base for %q and %q: %w", baseRef, headRef, err)
 	}
-
-	mergeBase := strings.TrimSpace(string(mergeBaseOut))
-	if mergeBase == "" {
+	if mergeBase == "" && !rng.TwoDot {
 		return nil, fmt.Errorf("merge-base for %q and %q: empty output", baseRef, headRef)
 	}
 
@@ -59,7 +90,12 @@ func (g *Repo) Compare(baseRef, headRef string) (*Compare, error) {
 		return nil, err
 	}
 
-	diff, err := g.diffBetween(plumbing.NewHash(mergeBase), headHash)
+	diffBase := mergeBase
+	if rng.TwoDot {
+		diffBase = baseHash.String()
+	}
+
+	diff, err := g.diffBetween(ctx, plumbing.NewHash(diffBase), headHash, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +105,9 @@ func (g *Repo) Compare(baseRef, headRef string) (*Compare, error) {
 		HeadRef:   headRef,
 		BaseHash:  baseHash.String(),
 		HeadHash:  headHash.String(),
+		TwoDot:    rng.TwoDot,
 		MergeBase: mergeBase,
+		DiffBase:  diffBase,
 		Ahead:     ahead,
 		Behind:    behind,
 		Commits:   commits,
@@ -77,6 +115,19 @@ func (g *Repo) Compare(baseRef, headRef string) (*Compare, error) {
 	}, nil
 }
 
+func (g *Repo) resolveRange(baseRef, headRef string) (plumbing.Hash, plumbing.Hash, error) {
+	base, err := g.resolveRef(baseRef)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("resolving base ref %q: %w", baseRef, err)
+	}
+
+	head, err := g.resolveRef(headRef)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("resolving head ref %q: %w", headRef, err)
+	}
+	return base, head, nil
+}
+
 func (g *Repo) resolveRef(ref string) (plumbing.Hash, error) {
 	hash, err := g.r.ResolveRevision(plumbing.Revision(ref))
 	if err != nil {
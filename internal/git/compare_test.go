@@ -17,7 +17,7 @@ func TestRepo_Compare(t *testing.T) {
 		r.checkoutBranch("develop", false)
 		r.commitFile("develop.txt", "develop only\n", "develop change")
 
-		cmp, err := r.open().Compare("master", "develop")
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "master", Head: "develop"}, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, cmp.BaseRef, "master")
 		is.Equal(t, cmp.HeadRef, "develop")
@@ -34,7 +34,7 @@ func TestRepo_Compare(t *testing.T) {
 		r := newTestRepo(t)
 		r.commitFile("README.md", "base\n", "base commit")
 
-		cmp, err := r.open().Compare("master", "master")
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "master", Head: "master"}, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, cmp.Behind, 0)
 		is.Equal(t, cmp.Ahead, 0)
@@ -46,7 +46,7 @@ func TestRepo_Compare(t *testing.T) {
 		r := newTestRepo(t)
 		r.commitFile("README.md", "base\n", "base commit")
 
-		_, err := r.open().Compare("master", "does-not-exist")
+		_, err := r.open().Compare(t.Context(), Range{Base: "master", Head: "does-not-exist"}, DiffOptions{})
 		is.Err(t, err, "resolving head ref")
 	})
 
@@ -58,7 +58,7 @@ func TestRepo_Compare(t *testing.T) {
 		r.checkoutBranch("develop", false)
 		r.commitFile("develop.txt", "develop only\n", "develop change")
 
-		cmp, err := r.open().Compare("v1.0", "develop")
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "v1.0", Head: "develop"}, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, cmp.BaseRef, "v1.0")
 		is.Equal(t, cmp.HeadRef, "develop")
@@ -75,7 +75,7 @@ func TestRepo_Compare(t *testing.T) {
 		r.checkoutBranch("develop", false)
 		r.commitFile("develop.txt", "develop only\n", "develop change")
 
-		cmp, err := r.open().Compare("v1.0", "develop")
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "v1.0", Head: "develop"}, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, cmp.BaseRef, "v1.0")
 		is.Equal(t, cmp.HeadRef, "develop")
@@ -90,8 +90,66 @@ func TestRepo_Compare(t *testing.T) {
 		r.createAnnotatedTag("v1.0", "v1.0", base, time.Now())
 		r.createAnnotatedTag("v2.0", "v2.0", head, time.Now())
 
-		cmp, err := r.open().Compare("v1.0", "v2.0")
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "v1.0", Head: "v2.0"}, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, cmp.Ahead, 1)
 	})
+	t.Run("detects renames", func(t *testing.T) {
+		r := newTestRepo(t)
+		r.commitFile("old.txt", "a\nb\nc\nd\n", "base commit")
+		r.createTag("v1.0", r.commitFile("README.md", "readme\n", "add readme"))
+		r.moveFile("old.txt", "new.txt", "a\nb\nc\nd\n", "rename")
+		r.createTag("v2.0", r.commitFile("README.md", "readme\nmore\n", "update readme"))
+
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "v1.0", Head: "v2.0"}, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, cmp.Diff.Stat.FilesChanged, 2)
+		is.Equal(t, cmp.Diff.Diff[1].IsRename, true)
+		is.Equal(t, cmp.Diff.Diff[1].Name.Old, "old.txt")
+		is.Equal(t, cmp.Diff.Diff[1].Similarity, 100)
+	})
+	t.Run("two-dot range diffs against base tip", func(t *testing.T) {
+		r := newTestRepo(t)
+		base := r.commitFile("README.md", "base\n", "base commit")
+		r.createBranch("develop", base)
+		r.commitFile("master.txt", "master only\n", "master change")
+		r.checkoutBranch("develop", false)
+		r.commitFile("develop.txt", "develop only\n", "develop change")
+
+		cmp, err := r.open().Compare(t.Context(), Range{Base: "master", Head: "develop", TwoDot: true}, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, cmp.TwoDot, true)
+		is.Equal(t, cmp.MergeBase, base.String())
+		is.Equal(t, cmp.DiffBase, cmp.BaseHash)
+		is.Equal(t, len(cmp.Commits), 1)
+		is.Equal(t, cmp.Diff.Stat.FilesChanged, 2) // master.txt is "deleted"
+		is.Equal(t, cmp.Diff.Diff[1].Name.Old, "master.txt")
+		is.Equal(t, cmp.Diff.Diff[1].IsDelete, true)
+	})
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Range
+		ok   bool
+	}{
+		{"main...feature", Range{Base: "main", Head: "feature"}, true},
+		{"main..feature", Range{Base: "main", Head: "feature", TwoDot: true}, true},
+		{"v1.0...v2.0", Range{Base: "v1.0", Head: "v2.0"}, true},
+		{"feature/a...main~2", Range{Base: "feature/a", Head: "main~2"}, true},
+		{"main", Range{}, false},
+		{"main...", Range{Base: "main"}, false},
+		{"..main", Range{Head: "main", TwoDot: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParseRange(tt.in)
+			is.Equal(t, ok, tt.ok)
+			is.Equal(t, got, tt.want)
+			if ok {
+				is.Equal(t, got.String(), tt.in)
+			}
+		})
+	}
 }
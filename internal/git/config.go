@@ -23,6 +23,23 @@ func (g *Repo) SetPrivate(isPrivate bool) error {
 	return g.setOption("private", strconv.FormatBool(isPrivate))
 }
 
+// Section is a heading the repo is grouped under on the index page.
+func (g *Repo) Section() (string, error) {
+	return g.readOption("section")
+}
+
+func (g *Repo) SetSection(section string) error {
+	if section == "" {
+		c, err := g.r.Config()
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		c.Raw.Section("mugit").RemoveOption("section")
+		return g.r.SetConfig(c)
+	}
+	return g.setOption("section", section)
+}
+
 const originRemote = "origin"
 
 func (g *Repo) IsMirror() (bool, error) {
@@ -48,6 +65,89 @@ func (g *Repo) SetMirrorRemote(url string) error {
 	return nil
 }
 
+// mirrorOptions are repo options that only make sense for mirrors.
+var mirrorOptions = []string{
+	"last-sync",
+	"last-checked",
+	"mirror-interval",
+	"mirror-include",
+	"mirror-exclude",
+}
+
+// UnsetMirror turns a mirror into a regular repository, and clears all
+// mirror related options. If keepAs isn't empty, the origin remote is kept
+// under that name as a regular remote, otherwise it's removed.
+func (g *Repo) UnsetMirror(keepAs string) error {
+	c, err := g.r.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	origin, ok := c.Remotes[originRemote]
+	if !ok || !origin.Mirror {
+		return fmt.Errorf("repository is not a mirror")
+	}
+	delete(c.Remotes, originRemote)
+
+	if keepAs != "" {
+		if _, exists := c.Remotes[keepAs]; exists {
+			return fmt.Errorf("remote %q already exists", keepAs)
+		}
+
+		remote := &gitconfig.RemoteConfig{
+			Name: keepAs,
+			URLs: origin.URLs,
+			Fetch: []gitconfig.RefSpec{
+				gitconfig.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", keepAs)),
+			},
+		}
+		if err := remote.Validate(); err != nil {
+			return fmt.Errorf("invalid remote name %q: %w", keepAs, err)
+		}
+		c.Remotes[keepAs] = remote
+	}
+
+	s := c.Raw.Section("mugit")
+	for _, key := range mirrorOptions {
+		s.RemoveOption(key)
+	}
+
+	return g.r.SetConfig(c)
+}
+
+// MirrorRefFilter returns refs filter of the mirror, see [RefFilter].
+func (g *Repo) MirrorRefFilter() (RefFilter, error) {
+	include, err := g.readOptionAll("mirror-include")
+	if err != nil {
+		return RefFilter{}, err
+	}
+
+	exclude, err := g.readOptionAll("mirror-exclude")
+	if err != nil {
+		return RefFilter{}, err
+	}
+
+	return RefFilter{Include: include, Exclude: exclude}, nil
+}
+
+func (g *Repo) SetMirrorRefFilter(f RefFilter) error {
+	c, err := g.r.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	s := c.Raw.Section("mugit")
+	s.RemoveOption("mirror-include")
+	s.RemoveOption("mirror-exclude")
+	for _, p := range f.Include {
+		s.AddOption("mirror-include", p)
+	}
+	for _, p := range f.Exclude {
+		s.AddOption("mirror-exclude", p)
+	}
+	return g.r.SetConfig(c)
+}
+
 func (g *Repo) RemoteURL() (string, error) {
 	r, err := g.r.Remote(originRemote)
 	if err != nil {
@@ -125,6 +225,29 @@ func (g *Repo) SetLastChecked(lastChecked time.Time) error {
 	return g.setOption("last-checked", lastChecked.Format(time.RFC3339))
 }
 
+// MirrorInterval returns per-repo mirror sync interval, or 0 if it's not set.
+func (g *Repo) MirrorInterval() (time.Duration, error) {
+	raw, err := g.readOption("mirror-interval")
+	if err != nil {
+		return 0, err
+	}
+
+	if raw == "" {
+		return 0, nil
+	}
+
+	out, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse mirror interval: %w", err)
+	}
+
+	return out, nil
+}
+
+func (g *Repo) SetMirrorInterval(interval time.Duration) error {
+	return g.setOption("mirror-interval", interval.String())
+}
+
 func (g *Repo) readOption(key string) (string, error) {
 	c, err := g.r.Config()
 	if err != nil {
@@ -133,6 +256,14 @@ func (g *Repo) readOption(key string) (string, error) {
 	return c.Raw.Section("mugit").Options.Get(key), nil
 }
 
+func (g *Repo) readOptionAll(key string) ([]string, error) {
+	c, err := g.r.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return c.Raw.Section("mugit").Options.GetAll(key), nil
+}
+
 func (g *Repo) setOption(key, value string) error {
 	c, err := g.r.Config()
 	if err != nil {
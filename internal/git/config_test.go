@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"olexsmir.xyz/x/is"
 )
@@ -37,6 +38,24 @@ func TestRepo_IsPrivate(t *testing.T) {
 	})
 }
 
+func TestRepo_Section(t *testing.T) {
+	r := newTestRepo(t).open()
+
+	section, err := r.Section()
+	is.Err(t, err, nil)
+	is.Equal(t, section, "")
+
+	is.Err(t, r.SetSection("tools"), nil)
+	section, err = r.Section()
+	is.Err(t, err, nil)
+	is.Equal(t, section, "tools")
+
+	is.Err(t, r.SetSection(""), nil)
+	section, err = r.Section()
+	is.Err(t, err, nil)
+	is.Equal(t, section, "")
+}
+
 func TestRepo_Description(t *testing.T) {
 	t.Run("default description is empty description", func(t *testing.T) {
 		r := newTestRepo(t)
@@ -125,3 +144,65 @@ func TestRepo_Mirror(t *testing.T) {
 		is.Equal(t, url, expectedURL)
 	})
 }
+
+func TestRepo_UnsetMirror(t *testing.T) {
+	t.Run("removes origin and mirror options", func(t *testing.T) {
+		r := newTestRepo(t).open()
+		is.Err(t, r.SetMirrorRemote("https://github.com/example/repo.git"), nil)
+		is.Err(t, r.SetLastSync(time.Now()), nil)
+		is.Err(t, r.SetMirrorInterval(time.Hour), nil)
+
+		is.Err(t, r.UnsetMirror(""), nil)
+
+		_, err := r.IsMirror()
+		is.Err(t, err, "failed to get remote: ")
+
+		_, err = r.LastSync()
+		is.Err(t, err, "last-sync not set")
+
+		interval, err := r.MirrorInterval()
+		is.Err(t, err, nil)
+		is.Equal(t, interval, time.Duration(0))
+	})
+
+	t.Run("keeps remote under new name", func(t *testing.T) {
+		r := newTestRepo(t).open()
+		is.Err(t, r.SetMirrorRemote("https://github.com/example/repo.git"), nil)
+		is.Err(t, r.UnsetMirror("upstream"), nil)
+
+		rmt, err := r.r.Remote("upstream")
+		is.Err(t, err, nil)
+		is.Equal(t, rmt.Config().Mirror, false)
+		is.Equal(t, rmt.Config().URLs, []string{"https://github.com/example/repo.git"})
+	})
+
+	t.Run("fails on non mirror", func(t *testing.T) {
+		r := newTestRepo(t).open()
+		is.Err(t, r.UnsetMirror(""), "repository is not a mirror")
+	})
+}
+
+func TestRepo_MirrorInterval(t *testing.T) {
+	t.Run("unset interval is zero", func(t *testing.T) {
+		interval, err := newTestRepo(t).open().MirrorInterval()
+		is.Err(t, err, nil)
+		is.Equal(t, interval, time.Duration(0))
+	})
+
+	t.Run("set and get interval", func(t *testing.T) {
+		r := newTestRepo(t).open()
+		is.Err(t, r.SetMirrorInterval(168*time.Hour), nil)
+
+		interval, err := r.MirrorInterval()
+		is.Err(t, err, nil)
+		is.Equal(t, interval, 168*time.Hour)
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		r := newTestRepo(t).open()
+		is.Err(t, r.setOption("mirror-interval", "weekly"), nil)
+
+		_, err := r.MirrorInterval()
+		is.Err(t, err, "failed to parse mirror interval")
+	})
+}
@@ -1,19 +1,103 @@
 package git
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
+	"path"
 	"strings"
 
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 type TextFragment struct {
 	Header      string
 	Lines       []gitdiff.Line
+	Highlighted []template.HTML // highlighted Lines, filled by the caller, see [TextFragment.LineHTML]
 	OldPosition int64
 	NewPosition int64
+
+	// Changes are changed words of lines, which are paired with a line on
+	// the other side of the diff. Indexed like Lines, nil if nothing is paired.
+	Changes [][]Span
+
+	// Ops are per parent operations of lines of a combined diff, e.g. " +"
+	// for a line added relative to the second parent. Nil for other diffs.
+	Ops []string
+}
+
+// SplitLine is a row of side-by-side diff. Deleted and added lines of a
+// change are paired, side without a line has zero Number.
+type SplitLine struct {
+	Old, New SplitSide
+}
+
+type SplitSide struct {
+	Number int64
+	Op     string // "+", "-", or " " for context
+	HTML   template.HTML
+}
+
+// LineHTML returns i-th line of the fragment as html. Highlighted line is
+// used, if it's set, otherwise the line is escaped.
+func (tf TextFragment) LineHTML(i int) template.HTML {
+	if i < len(tf.Highlighted) {
+		return template.HTML(strings.TrimSuffix(string(tf.Highlighted[i]), "\n"))
+	}
+	return template.HTML(template.HTMLEscapeString(strings.TrimSuffix(tf.Lines[i].Line, "\n")))
+}
+
+// Split lays out lines of the fragment in two columns, old on the left, and
+// new on the right.
+func (tf TextFragment) Split() []SplitLine {
+	line := tf.LineHTML
+
+	var out []SplitLine
+	var dels, adds []SplitSide
+	flush := func() {
+		for i := range max(len(dels), len(adds)) {
+			var sl SplitLine
+			if i < len(dels) {
+				sl.Old = dels[i]
+			}
+			if i < len(adds) {
+				sl.New = adds[i]
+			}
+			out = append(out, sl)
+		}
+		dels, adds = dels[:0], adds[:0]
+	}
+
+	o, n := tf.OldPosition, tf.NewPosition
+	for i, l := range tf.Lines {
+		switch l.Op {
+		case gitdiff.OpDelete:
+			if len(adds) > 0 { // deletion after additions starts a new change
+				flush()
+			}
+			dels = append(dels, SplitSide{Number: o, Op: "-", HTML: line(i)})
+			o++
+		case gitdiff.OpAdd:
+			adds = append(adds, SplitSide{Number: n, Op: "+", HTML: line(i)})
+			n++
+		default:
+			flush()
+			out = append(out, SplitLine{
+				Old: SplitSide{Number: o, Op: " ", HTML: line(i)},
+				New: SplitSide{Number: n, Op: " ", HTML: line(i)},
+			})
+			o++
+			n++
+		}
+	}
+	flush()
+	return out
 }
 
 type Diff struct {
@@ -26,6 +110,20 @@ type Diff struct {
 	IsNew         bool
 	IsDelete      bool
 	IsRename      bool
+	IsCopy        bool
+	Similarity    int // of renamed or copied file, in percents
+
+	// TooLarge is set when the file is over diff limits, its fragments are
+	// omitted.
+	TooLarge bool
+
+	// Collapsed is the reason why the file is collapsed by default:
+	// "generated", "lockfile", or "no diff". Empty if it isn't.
+	Collapsed string
+
+	// Combined is set for combined diffs of merge commits, their fragments
+	// have Ops, and only positions in the new file.
+	Combined bool
 }
 
 type NiceDiff struct {
@@ -39,127 +137,284 @@ type NiceDiff struct {
 	}
 }
 
-func (g *Repo) Diff() (*NiceDiff, error) {
+// DiffOptions bound size of a diff, zero means no limit. Files over the limits
+// are listed without their content.
+type DiffOptions struct {
+	MaxFileLines int
+	MaxFileBytes int
+	MaxLines     int // of all files
+	MaxBytes     int
+
+	// Similarity is minimal similarity, in percents, of files to be detected
+	// as renamed or copied. Zero means git's default, 50%.
+	Similarity int
+}
+
+// Parents of merge commits to diff against, besides a parent number.
+const (
+	// DiffCombined shows combined diff against all parents, only files
+	// that differ from each of them are shown.
+	DiffCombined = 0
+
+	// DiffRemerge diffs against automatic re-merge of the parents, so only
+	// changes introduced by the merge, like conflict resolutions, are shown.
+	DiffRemerge = -1
+)
+
+// Diff returns changes of the commit. Merge commits are diffed against the
+// parent-th parent (1-based), or with DiffCombined, or DiffRemerge. Other
+// commits are diffed against their only parent.
+func (g *Repo) Diff(ctx context.Context, parent int, opts DiffOptions) (*NiceDiff, error) {
 	c, err := g.r.CommitObject(g.h)
 	if err != nil {
 		return nil, fmt.Errorf("commit object: %w", err)
 	}
 
-	patch, parents, err := g.getPatch(c)
-	if err != nil {
-		return nil, err
+	cmd, hash := "diff-tree", c.Hash.String()
+	var args []string
+	switch n := c.NumParents(); {
+	case parent > n:
+		return nil, fmt.Errorf("%w: %d", ErrNoParent, parent)
+	case n == 0:
+		args = []string{"--root", hash}
+	case n == 1 || parent > 0:
+		args = []string{c.ParentHashes[max(parent, 1)-1].String(), hash}
+	case parent == DiffCombined:
+		args = []string{"--cc", hash}
+	case parent == DiffRemerge:
+		// diff-tree doesn't support --remerge-diff
+		cmd, args = "show", []string{"--format=", "--remerge-diff", hash}
+	default:
+		return nil, fmt.Errorf("invalid parent %d", parent)
 	}
 
-	nd, err := parseNiceDiff(patch.String())
+	nd, err := g.streamDiff(ctx, opts, cmd, args...)
 	if err != nil {
 		return nil, err
 	}
+
 	nd.Commit = newCommit(c)
-	nd.Parents = parents
+	nd.Parents = make([]string, len(c.ParentHashes))
+	for i, h := range c.ParentHashes {
+		nd.Parents[i] = newShortHash(h)
+	}
 	return nd, nil
 }
 
-func parseNiceDiff(patch string) (*NiceDiff, error) {
-	nd := &NiceDiff{}
-	if strings.TrimSpace(patch) == "" {
-		return nd, nil
+func (g *Repo) diffBetween(ctx context.Context, base, head plumbing.Hash, opts DiffOptions) (*NiceDiff, error) {
+	diff, err := g.streamDiff(ctx, opts, "diff-tree", base.String(), head.String())
+	if err != nil {
+		return nil, fmt.Errorf("diff %s..%s: %w", base, head, err)
 	}
+	return diff, nil
+}
 
-	diffs, _, err := gitdiff.Parse(strings.NewReader(patch))
+// streamDiff runs git diff command, and reads its output file by file,
+// content over the limits is skipped without being kept in memory.
+func (g *Repo) streamDiff(ctx context.Context, opts DiffOptions, cmd string, args ...string) (*NiceDiff, error) {
+	renames, copies := "-M", "-C"
+	if opts.Similarity > 0 {
+		renames = fmt.Sprintf("-M%d%%", opts.Similarity)
+		copies = fmt.Sprintf("-C%d%%", opts.Similarity)
+	}
+
+	// prefixes are pinned, porcelain commands, like show, respect diff.noprefix
+	// and diff.mnemonicPrefix, and the parser needs a/ and b/
+	args = append([]string{
+		cmd, "-r", "-p", renames, copies, "--no-commit-id", "--no-color", "--no-ext-diff",
+		"--src-prefix=a/", "--dst-prefix=b/",
+	}, args...)
+	rc, err := g.streamingGit(ctx, args...)
 	if err != nil {
-		return nil, fmt.Errorf("parsing diff: %w", err)
+		return nil, err
 	}
 
-	nd.Stat.FilesChanged = len(diffs)
-	nd.Diff = make([]Diff, len(diffs))
-	for i, d := range diffs {
-		diff := &nd.Diff[i]
-		diff.Name.New = d.NewName
-		if d.OldName != d.NewName {
-			diff.Name.Old = d.OldName
+	nd, err := readDiff(rc, opts, g.Attributes())
+	return nd, errors.Join(err, rc.Close())
+}
+
+func readDiff(r io.Reader, opts DiffOptions, attrs Attributes) (*NiceDiff, error) {
+	nd := &NiceDiff{}
+	var f diffFile
+	var totalLines, totalBytes int
+
+	flush := func() error {
+		if f.buf.Len() == 0 {
+			return nil
 		}
-		diff.IsBinary = d.IsBinary
-		diff.IsNew = d.IsNew
-		diff.IsDelete = d.IsDelete
-		diff.IsRename = d.IsRename
-
-		for _, tf := range d.TextFragments {
-			diff.TextFragments = append(diff.TextFragments, TextFragment{
-				Header:      tf.Header(),
-				Lines:       tf.Lines,
-				OldPosition: tf.OldPosition,
-				NewPosition: tf.NewPosition,
-			})
-			for _, l := range tf.Lines {
-				switch l.Op {
-				case gitdiff.OpAdd:
-					nd.Stat.Insertions += 1
-				case gitdiff.OpDelete:
-					nd.Stat.Deletions += 1
-				}
-			}
+		if !f.tooLarge {
+			totalLines += f.lines
+			totalBytes += f.size
 		}
-	}
-	return nd, nil
-}
 
-func (g *Repo) getPatch(c *object.Commit) (*object.Patch, []string, error) {
-	commitTree, err := c.Tree()
-	if err != nil {
-		return nil, nil, err
+		diff, err := f.parse()
+		if err != nil {
+			return err
+		}
+		diff.Collapsed = collapseReason(cmp.Or(diff.Name.New, diff.Name.Old), attrs)
+		nd.Diff = append(nd.Diff, diff)
+		f.reset()
+		return nil
 	}
 
-	parentTree := &object.Tree{}
-	if c.NumParents() > 0 {
-		parent, perr := c.Parents().Next()
-		if perr != nil {
-			return nil, nil, perr
+	over := func(n, limit int) bool { return limit > 0 && n > limit }
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart := true
+	for {
+		// long lines are read in chunks, only first chunk starts a line
+		line, err := br.ReadSlice('\n')
+		if len(line) > 0 {
+			if lineStart && (bytes.HasPrefix(line, []byte("diff --git ")) || bytes.HasPrefix(line, []byte("diff --cc "))) {
+				if ferr := flush(); ferr != nil {
+					return nil, ferr
+				}
+			}
+
+			switch {
+			case !f.inHunk && lineStart && bytes.HasPrefix(line, []byte("remerge ")):
+				// "remerge CONFLICT ..." of remerge diffs, go-gitdiff doesn't expect it
+
+			case !f.inHunk && !(lineStart && bytes.HasPrefix(line, []byte("@@"))):
+				f.buf.Write(line) // file header
+
+			default:
+				if !f.inHunk {
+					f.inHunk = true
+					f.header = f.buf.Len()
+				}
+				if lineStart {
+					// combined diffs have a column of ops per parent
+					ops := line[:min(max(f.cols, 1), len(line))]
+					switch {
+					case ops[0] == '@': // hunk header, @@@ for combined diff of two parents
+						f.cols = len(line) - len(bytes.TrimLeft(line, "@")) - 1
+					case ops[0] == '\\': // no newline at end of file
+					case bytes.IndexByte(ops, '+') >= 0:
+						f.lines++
+						nd.Stat.Insertions++
+					case bytes.IndexByte(ops, '-') >= 0:
+						f.lines++
+						nd.Stat.Deletions++
+					default:
+						f.lines++
+					}
+				}
+				f.size += len(line)
+
+				if !f.tooLarge && (over(f.lines, opts.MaxFileLines) || over(f.size, opts.MaxFileBytes) ||
+					over(totalLines+f.lines, opts.MaxLines) || over(totalBytes+f.size, opts.MaxBytes)) {
+					f.tooLarge = true
+					f.buf.Truncate(f.header)
+				}
+				if !f.tooLarge {
+					f.buf.Write(line)
+				}
+			}
+			lineStart = line[len(line)-1] == '\n'
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
 		}
-		if parentTree, err = parent.Tree(); err != nil {
-			return nil, nil, err
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("reading diff: %w", err)
 		}
 	}
-
-	patch, err := parentTree.Patch(commitTree)
-	if err != nil {
-		return nil, nil, fmt.Errorf("patch: %w", err)
+	if err := flush(); err != nil {
+		return nil, err
 	}
 
-	parents := make([]string, len(c.ParentHashes))
-	for i, h := range c.ParentHashes {
-		parents[i] = newShortHash(h)
-	}
+	nd.Stat.FilesChanged = len(nd.Diff)
+	return nd, nil
+}
 
-	return patch, parents, nil
+// diffFile is a diff of a single file, as it's read.
+type diffFile struct {
+	buf      bytes.Buffer
+	header   int // length of the header in buf
+	inHunk   bool
+	cols     int // number of op columns, more than one in combined diffs
+	lines    int // of hunks
+	size     int
+	tooLarge bool
 }
 
-func (g *Repo) diffBetween(base, head plumbing.Hash) (*NiceDiff, error) {
-	baseCommit, err := g.r.CommitObject(base)
-	if err != nil {
-		return nil, fmt.Errorf("base commit object %s: %w", base, err)
-	}
-	headCommit, err := g.r.CommitObject(head)
-	if err != nil {
-		return nil, fmt.Errorf("head commit object %s: %w", head, err)
+func (f *diffFile) reset() {
+	f.buf.Reset()
+	f.header, f.inHunk, f.cols, f.lines, f.size, f.tooLarge = 0, false, 0, 0, 0, false
+}
+
+func (f *diffFile) parse() (Diff, error) {
+	if bytes.HasPrefix(f.buf.Bytes(), []byte("diff --cc ")) {
+		diff, err := parseCombined(f.buf.Bytes())
+		diff.TooLarge = f.tooLarge
+		return diff, err
 	}
 
-	baseTree, err := baseCommit.Tree()
+	files, _, err := gitdiff.Parse(&f.buf)
 	if err != nil {
-		return nil, fmt.Errorf("base tree %s: %w", base, err)
+		return Diff{}, fmt.Errorf("parsing diff: %w", err)
 	}
-	headTree, err := headCommit.Tree()
-	if err != nil {
-		return nil, fmt.Errorf("head tree %s: %w", head, err)
+	if len(files) != 1 {
+		return Diff{}, fmt.Errorf("parsing diff: expected single file, got %d", len(files))
 	}
 
-	patch, err := baseTree.Patch(headTree)
-	if err != nil {
-		return nil, fmt.Errorf("tree patch %s..%s: %w", base, head, err)
+	d := files[0]
+	diff := Diff{
+		IsBinary:   d.IsBinary,
+		IsNew:      d.IsNew,
+		IsDelete:   d.IsDelete,
+		IsRename:   d.IsRename,
+		IsCopy:     d.IsCopy,
+		Similarity: d.Score,
+		TooLarge:   f.tooLarge,
+	}
+	diff.Name.New = d.NewName
+	if d.OldName != d.NewName {
+		diff.Name.Old = d.OldName
 	}
 
-	diff, err := parseNiceDiff(patch.String())
-	if err != nil {
-		return nil, fmt.Errorf("parse tree diff %s..%s: %w", base, head, err)
+	for _, tf := range d.TextFragments {
+		diff.TextFragments = append(diff.TextFragments, TextFragment{
+			Header:      tf.Header(),
+			Lines:       tf.Lines,
+			Changes:     lineChanges(tf.Lines),
+			OldPosition: tf.OldPosition,
+			NewPosition: tf.NewPosition,
+		})
 	}
 	return diff, nil
 }
+
+// lockfiles are generated by package managers, their diffs are rarely read.
+var lockfiles = map[string]struct{}{
+	"Cargo.lock":         {},
+	"composer.lock":      {},
+	"flake.lock":         {},
+	"Gemfile.lock":       {},
+	"go.sum":             {},
+	"mix.lock":           {},
+	"package-lock.json":  {},
+	"Pipfile.lock":       {},
+	"pnpm-lock.yaml":     {},
+	"poetry.lock":        {},
+	"pubspec.lock":       {},
+	"Podfile.lock":       {},
+	"uv.lock":            {},
+	"yarn.lock":          {},
+	"bun.lock":           {},
+	"packages.lock.json": {},
+}
+
+func collapseReason(filePath string, attrs Attributes) string {
+	switch {
+	case attrs.Generated(filePath):
+		return "generated"
+	case attrs.NoDiff(filePath):
+		return "no diff"
+	}
+	if _, ok := lockfiles[path.Base(filePath)]; ok {
+		return "lockfile"
+	}
+	return ""
+}
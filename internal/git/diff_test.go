@@ -1,6 +1,9 @@
 package git
 
 import (
+	"cmp"
+	"html/template"
+	"strings"
 	"testing"
 
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
@@ -14,7 +17,7 @@ func TestRepo_Diff(t *testing.T) {
 		r.commitFile("README.md", "# Test", "Initial commit")
 		r.commitFile("hello.txt", "hello world\n", "Add hello file")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, diff.Stat.FilesChanged, 1)
 		is.Equal(t, diff.Stat.Insertions, 1)
@@ -29,7 +32,7 @@ func TestRepo_Diff(t *testing.T) {
 		r.commitFile("README.md", "# Original\n", "Initial commit")
 		r.commitFile("README.md", "# Modified\n\nNew content here.\n", "Update README")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, diff.Stat.FilesChanged, 1)
 		is.Equal(t, diff.Diff[0].Name.New, "README.md")
@@ -48,7 +51,7 @@ func TestRepo_Diff(t *testing.T) {
 		r.commitFile("todelete.txt", "temp content\n", "Add temp file")
 		r.deleteFile("todelete.txt", "Delete temp file")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, diff.Stat.FilesChanged, 1)
 		is.Equal(t, diff.Stat.Deletions, 1)
@@ -61,7 +64,7 @@ func TestRepo_Diff(t *testing.T) {
 		r.commitFile("file2.txt", "content 2\n", "Add file2")
 		r.commitFile("file3.txt", "content 3\n", "Add file3")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, diff.Stat.FilesChanged, 1)
 		is.Equal(t, diff.Stat.Insertions, 1)
@@ -72,7 +75,7 @@ func TestRepo_Diff(t *testing.T) {
 		r.commitFile("first.txt", "first\n", "First commit")
 		r.commitFile("second.txt", "second file\n", "Add second file")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, len(diff.Parents), 1)
 		if len(diff.Parents[0]) == 0 {
@@ -91,7 +94,7 @@ func TestRepo_Diff(t *testing.T) {
 		}
 
 		initial := r.open(commits[len(commits)-1].Hash)
-		diff, err := initial.Diff()
+		diff, err := initial.Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Equal(t, len(diff.Parents), 0)
 		is.Err(t, err, nil)
 	})
@@ -101,7 +104,7 @@ func TestRepo_Diff(t *testing.T) {
 		r.commitFile("README.md", "original\n", "Initial commit")
 		r.commitFile("README.md", "line 1\nline 2\nline 3\n", "Multi-line change")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		if len(diff.Diff) == 0 {
 			t.Fatal("expected at least one diff")
@@ -131,7 +134,7 @@ func TestRepo_Diff(t *testing.T) {
 		r := newTestRepo(t)
 		r.commitFile("info.txt", "test\n", "Test commit message")
 
-		diff, err := r.open().Diff()
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
 		is.Err(t, err, nil)
 		is.Equal(t, diff.Commit.Message, "Test commit message")
 		is.Equal(t, diff.Commit.AuthorName, "Test User")
@@ -142,6 +145,241 @@ func TestRepo_Diff(t *testing.T) {
 	})
 }
 
+func TestRepo_Diff_renames(t *testing.T) {
+	content := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	r := newTestRepo(t)
+	r.commitFile("old.txt", content, "Initial commit")
+	r.moveFile("old.txt", "new.txt", strings.Replace(content, "5\n", "five\n", 1), "Rename")
+
+	t.Run("detected", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, len(diff.Diff), 1)
+
+		d := diff.Diff[0]
+		is.Equal(t, d.IsRename, true)
+		is.Equal(t, d.Name.Old, "old.txt")
+		is.Equal(t, d.Name.New, "new.txt")
+		is.Equal(t, d.Similarity, 79)
+		is.Equal(t, diff.Stat.Insertions, 1)
+		is.Equal(t, diff.Stat.Deletions, 1)
+	})
+
+	t.Run("under similarity", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{Similarity: 95})
+		is.Err(t, err, nil)
+		is.Equal(t, len(diff.Diff), 2)
+		is.Equal(t, diff.Diff[0].IsRename, false)
+		is.Equal(t, diff.Stat.Insertions, 10)
+		is.Equal(t, diff.Stat.Deletions, 10)
+	})
+}
+
+func TestRepo_Diff_merge(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("f.txt", "a\nb\nc\n", "Initial commit")
+	r.checkoutBranch("side", true)
+	r.commitFile("f.txt", "a\nB\nc\n", "Change b")
+	side := r.commitFile("side.txt", "side\n", "Add side")
+	r.checkoutBranch("master", false)
+	r.commitFile("f.txt", "a\nb2\nc\n", "Change b differently")
+	r.commitMerge("Merge side", side, map[string]string{
+		"f.txt":    "a\nresolved\nc\n",
+		"side.txt": "side\n",
+	})
+
+	names := func(nd *NiceDiff) []string {
+		var out []string
+		for _, d := range nd.Diff {
+			out = append(out, cmp.Or(d.Name.New, d.Name.Old))
+		}
+		return out
+	}
+
+	t.Run("combined", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, len(diff.Parents), 2)
+		is.Equal(t, names(diff), []string{"f.txt"}) // side.txt is the same as in side
+
+		d := diff.Diff[0]
+		is.Equal(t, d.Combined, true)
+		is.Equal(t, len(d.TextFragments), 1)
+		tf := d.TextFragments[0]
+		is.Equal(t, tf.NewPosition, int64(1))
+		is.Equal(t, tf.Ops, []string{"  ", "- ", " -", "++", "  "})
+		is.Equal(t, tf.Lines[3], gitdiff.Line{Op: gitdiff.OpAdd, Line: "resolved\n"})
+		is.Equal(t, tf.Lines[1].Op, gitdiff.OpDelete)
+		is.Equal(t, diff.Stat.Insertions, 1)
+		is.Equal(t, diff.Stat.Deletions, 2)
+	})
+
+	t.Run("parent", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), 1, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, names(diff), []string{"f.txt", "side.txt"})
+		is.Equal(t, diff.Diff[0].Combined, false)
+
+		diff, err = r.open().Diff(t.Context(), 2, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, names(diff), []string{"f.txt"})
+		is.Equal(t, diff.Stat.Insertions, 1)
+		is.Equal(t, diff.Stat.Deletions, 1)
+	})
+
+	t.Run("remerge", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), DiffRemerge, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, names(diff), []string{"f.txt"})
+
+		var added []string
+		for _, l := range diff.Diff[0].TextFragments[0].Lines {
+			if l.Op == gitdiff.OpAdd {
+				added = append(added, l.Line)
+			}
+		}
+		is.Equal(t, added, []string{"resolved\n"})
+		is.Equal(t, diff.Stat.Deletions, 5) // conflict markers and both sides
+	})
+
+	t.Run("remerge ignores host git config", func(t *testing.T) {
+		hostileGitConfig(t)
+
+		diff, err := r.open().Diff(t.Context(), DiffRemerge, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, names(diff), []string{"f.txt"})
+		is.Equal(t, diff.Stat.Insertions, 1)
+		is.Equal(t, diff.Stat.Deletions, 5)
+	})
+
+	t.Run("no such parent", func(t *testing.T) {
+		_, err := r.open().Diff(t.Context(), 3, DiffOptions{})
+		is.Err(t, err, ErrNoParent)
+	})
+
+	t.Run("not a merge", func(t *testing.T) {
+		diff, err := r.open("side").Diff(t.Context(), DiffCombined, DiffOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, names(diff), []string{"side.txt"})
+		is.Equal(t, diff.Diff[0].Combined, false)
+	})
+}
+
+func TestRepo_Diff_limits(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("small.txt", "a\n", "Initial commit")
+	r.commitFile("big.txt", strings.Repeat("line\n", 100), "Add big file")
+
+	t.Run("file lines", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{MaxFileLines: 50})
+		is.Err(t, err, nil)
+		is.Equal(t, diff.Diff[0].Name.New, "big.txt")
+		is.Equal(t, diff.Diff[0].TooLarge, true)
+		is.Equal(t, len(diff.Diff[0].TextFragments), 0)
+		is.Equal(t, diff.Stat.Insertions, 100)
+	})
+
+	t.Run("under limits", func(t *testing.T) {
+		diff, err := r.open().Diff(t.Context(), DiffCombined, DiffOptions{MaxFileLines: 200, MaxBytes: 1 << 20})
+		is.Err(t, err, nil)
+		is.Equal(t, diff.Diff[0].TooLarge, false)
+		is.Equal(t, len(diff.Diff[0].TextFragments[0].Lines), 100)
+	})
+}
+
+func TestReadDiff(t *testing.T) {
+	long := strings.Repeat("x", 100*1024) // longer than read buffer
+	raw := strings.Join([]string{
+		"diff --git a/a.txt b/a.txt",
+		"index 1111111..2222222 100644",
+		"--- a/a.txt",
+		"+++ b/a.txt",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+		"diff --git a/long.txt b/long.txt",
+		"new file mode 100644",
+		"index 0000000..3333333",
+		"--- /dev/null",
+		"+++ b/long.txt",
+		"@@ -0,0 +1,2 @@",
+		"+" + long,
+		"+short",
+		"diff --git a/go.sum b/go.sum",
+		"index 4444444..5555555 100644",
+		"--- a/go.sum",
+		"+++ b/go.sum",
+		"@@ -1 +1 @@",
+		"-a",
+		"+b",
+		"diff --git a/a.txt b/b.txt",
+		"similarity index 80%",
+		"copy from a.txt",
+		"copy to b.txt",
+		"index 1111111..8888888 100644",
+		"--- a/a.txt",
+		"+++ b/b.txt",
+		"@@ -1 +1 @@",
+		"-old",
+		"+copy",
+		"diff --git a/gen/api.go b/gen/api.go",
+		"index 6666666..7777777 100644",
+		"--- a/gen/api.go",
+		"+++ b/gen/api.go",
+		"@@ -1 +1 @@",
+		"-a",
+		"+b",
+		"",
+	}, "\n")
+	attrs := Attributes("gen/* linguist-generated\n")
+
+	t.Run("no limits", func(t *testing.T) {
+		nd, err := readDiff(strings.NewReader(raw), DiffOptions{}, attrs)
+		is.Err(t, err, nil)
+		is.Equal(t, nd.Stat.FilesChanged, 5)
+		is.Equal(t, nd.Stat.Insertions, 6)
+		is.Equal(t, nd.Stat.Deletions, 4)
+		is.Equal(t, nd.Diff[1].IsNew, true)
+		is.Equal(t, nd.Diff[1].TextFragments[0].Lines[0].Line, long+"\n")
+		is.Equal(t, nd.Diff[1].TextFragments[0].Lines[1].Line, "short\n")
+
+		var collapsed []string
+		for _, d := range nd.Diff {
+			collapsed = append(collapsed, d.Collapsed)
+		}
+		is.Equal(t, collapsed, []string{"", "", "lockfile", "", "generated"})
+
+		cp := nd.Diff[3]
+		is.Equal(t, cp.IsCopy, true)
+		is.Equal(t, cp.Name.Old, "a.txt")
+		is.Equal(t, cp.Name.New, "b.txt")
+		is.Equal(t, cp.Similarity, 80)
+	})
+
+	t.Run("file bytes", func(t *testing.T) {
+		nd, err := readDiff(strings.NewReader(raw), DiffOptions{MaxFileBytes: 1024}, attrs)
+		is.Err(t, err, nil)
+		is.Equal(t, nd.Stat.FilesChanged, 5)
+		is.Equal(t, nd.Stat.Insertions, 6)
+		is.Equal(t, nd.Diff[0].TooLarge, false)
+		is.Equal(t, nd.Diff[1].TooLarge, true)
+		is.Equal(t, nd.Diff[1].Name.New, "long.txt")
+		is.Equal(t, nd.Diff[1].IsNew, true)
+		is.Equal(t, len(nd.Diff[1].TextFragments), 0)
+		is.Equal(t, nd.Diff[2].TooLarge, false)
+	})
+
+	t.Run("total lines", func(t *testing.T) {
+		nd, err := readDiff(strings.NewReader(raw), DiffOptions{MaxLines: 5}, attrs)
+		is.Err(t, err, nil)
+		var tooLarge []bool
+		for _, d := range nd.Diff {
+			tooLarge = append(tooLarge, d.TooLarge)
+		}
+		is.Equal(t, tooLarge, []bool{false, false, true, true, true})
+	})
+}
+
 func TestTextFragment(t *testing.T) {
 	frag := TextFragment{
 		Header:      "@@ -1,3 +1,4 @@",
@@ -157,3 +395,83 @@ func TestTextFragment(t *testing.T) {
 	is.Equal(t, frag.NewPosition, int64(1))
 	is.Equal(t, len(frag.Lines), 2)
 }
+
+func TestTextFragment_Split(t *testing.T) {
+	frag := TextFragment{
+		OldPosition: 10,
+		NewPosition: 20,
+		Lines: []gitdiff.Line{
+			{Op: gitdiff.OpContext, Line: "a\n"},
+			{Op: gitdiff.OpDelete, Line: "b\n"},
+			{Op: gitdiff.OpDelete, Line: "c\n"},
+			{Op: gitdiff.OpAdd, Line: "B\n"},
+			{Op: gitdiff.OpContext, Line: "d\n"},
+			{Op: gitdiff.OpAdd, Line: "<e>\n"},
+			{Op: gitdiff.OpDelete, Line: "f\n"},
+		},
+	}
+
+	side := func(n int64, op, html string) SplitSide {
+		return SplitSide{Number: n, Op: op, HTML: template.HTML(html)}
+	}
+	is.Equal(t, frag.Split(), []SplitLine{
+		{Old: side(10, " ", "a"), New: side(20, " ", "a")},
+		{Old: side(11, "-", "b"), New: side(21, "+", "B")},
+		{Old: side(12, "-", "c")},
+		{Old: side(13, " ", "d"), New: side(22, " ", "d")},
+		{New: side(23, "+", "&lt;e&gt;")},
+		{Old: side(14, "-", "f")},
+	})
+
+	frag.Highlighted = make([]template.HTML, len(frag.Lines))
+	frag.Highlighted[0] = `<span class="hl-k">a</span>`
+	is.Equal(t, frag.Split()[0].Old.HTML, template.HTML(`<span class="hl-k">a</span>`))
+}
+
+func TestLineChanges(t *testing.T) {
+	lines := []gitdiff.Line{
+		{Op: gitdiff.OpContext, Line: "a\n"},
+		{Op: gitdiff.OpDelete, Line: "\treturn foo(1, bar)\n"},
+		{Op: gitdiff.OpDelete, Line: "x := 1\n"},
+		{Op: gitdiff.OpAdd, Line: "\treturn foo(2, bar)\n"},
+		{Op: gitdiff.OpAdd, Line: "something else\n"},
+		{Op: gitdiff.OpAdd, Line: "unpaired\n"},
+		{Op: gitdiff.OpContext, Line: "b\n"},
+	}
+
+	changes := lineChanges(lines)
+	is.Equal(t, len(changes), len(lines))
+	is.Equal(t, changes[1], []Span{{12, 13}})
+	is.Equal(t, changes[3], []Span{{12, 13}})
+	is.Equal(t, changes[2], nil) // nothing in common
+	is.Equal(t, changes[4], nil)
+	is.Equal(t, changes[5], nil)
+
+	is.Equal(t, lineChanges(lines[:2]), nil)
+}
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   string
+		aSpans []Span
+		bSpans []Span
+		ok     bool
+	}{
+		{"changed word", "hello world", "hello there", []Span{{6, 11}}, []Span{{6, 11}}, true},
+		{"added word", "foo(a)", "foo(a, b)", nil, []Span{{5, 8}}, true},
+		{"adjacent words merge", "a.b.c", "a-b-c", []Span{{1, 2}, {3, 4}}, []Span{{1, 2}, {3, 4}}, true},
+		{"unicode", "имя := 1", "имя := 2", []Span{{10, 11}}, []Span{{10, 11}}, true},
+		{"only whitespace in common", "foo bar", "baz qux", nil, nil, false},
+		{"same", "x", "x", nil, nil, false},
+		{"too long", strings.Repeat("a", wordDiffMaxLineLen+1), "a", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aSpans, bSpans, ok := wordDiff(tt.a, tt.b)
+			is.Equal(t, ok, tt.ok)
+			is.Equal(t, aSpans, tt.aSpans)
+			is.Equal(t, bSpans, tt.bSpans)
+		})
+	}
+}
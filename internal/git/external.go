@@ -58,9 +58,14 @@ func (g *Repo) runGitCmd(cmd string, args ...string) ([]byte, error) {
 func (g *Repo) streamingGitLog(ctx context.Context, extraArgs ...string) (io.ReadCloser, error) {
 	args := []string{"log", g.h.String()}
 	args = append(args, extraArgs...)
+	return g.streamingGit(ctx, args...)
+}
 
+// streamingGit runs git command, and returns its stdout. Process is cleaned up on Close.
+func (g *Repo) streamingGit(ctx context.Context, args ...string) (io.ReadCloser, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = g.path
+	cmd.Env = gitEnv
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -0,0 +1,283 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// fetchMemoryConfig bounds memory used by git while fetching and indexing
+// packs, so syncing a huge upstream doesn't eat all memory of the host.
+var fetchMemoryConfig = [][2]string{
+	{"pack.threads", "1"},
+	{"core.packedGitLimit", "256m"},
+	{"core.packedGitWindowSize", "32m"},
+	{"core.deltaBaseCacheLimit", "64m"},
+	{"core.bigFileThreshold", "64m"},
+}
+
+type FetchOptions struct {
+	// GithubToken is used to authenticate against github, if set.
+	GithubToken string
+
+	// Timeout is max duration of the fetch, zero means no limit.
+	Timeout time.Duration
+
+	// Progress receives git's progress output, if set.
+	Progress io.Writer
+}
+
+// Fetch syncs the mirror with its origin with external git, refs that don't
+// pass [Repo.MirrorRefFilter] are not fetched, and pruned if exist locally.
+// Reports whether any of refs, or HEAD, were changed.
+func (g *Repo) Fetch(ctx context.Context, opts FetchOptions) (isUpdated bool, err error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	filter, err := g.MirrorRefFilter()
+	if err != nil {
+		return false, err
+	}
+
+	before, err := g.refsSnapshot()
+	if err != nil {
+		return false, err
+	}
+
+	env := fetchEnv(opts.GithubToken)
+	remoteHead, err := g.remoteHead(ctx, env)
+	if err != nil {
+		return false, err
+	}
+
+	args := []string{"fetch", "--prune", "--no-tags", "--no-write-fetch-head"}
+	if opts.Progress != nil {
+		args = append(args, "--progress")
+	}
+	args = append(args, originRemote)
+	args = append(args, filter.refSpecs()...)
+
+	if err := g.fetchGit(ctx, env, nil, opts.Progress, args...); err != nil {
+		return false, fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	if err := g.pruneFiltered(filter); err != nil {
+		return false, err
+	}
+
+	if err := g.followRemoteHead(remoteHead); err != nil {
+		return false, err
+	}
+
+	after, err := g.refsSnapshot()
+	if err != nil {
+		return false, err
+	}
+
+	return !maps.Equal(before, after), nil
+}
+
+// refSpecs translates the filter into refspecs for git fetch. Exclusions
+// become negative refspecs, so excluded refs aren't even downloaded.
+func (f RefFilter) refSpecs() []string {
+	var out []string
+	if len(f.Include) == 0 {
+		out = append(out, "+refs/*:refs/*")
+	}
+	for _, pattern := range f.Include {
+		out = append(out, "+"+pattern+":"+pattern)
+	}
+	for _, pattern := range f.Exclude {
+		out = append(out, "^"+pattern)
+	}
+	return out
+}
+
+// remoteHead returns the ref remote's HEAD points to, or empty name if remote is empty.
+func (g *Repo) remoteHead(ctx context.Context, env []string) (plumbing.ReferenceName, error) {
+	var out bytes.Buffer
+	if err := g.fetchGit(ctx, env, &out, nil, "ls-remote", "--symref", originRemote, "HEAD"); err != nil {
+		return "", fmt.Errorf("failed to get remote HEAD: %w", err)
+	}
+
+	// ref: refs/heads/main	HEAD
+	for line := range strings.Lines(out.String()) {
+		target, ok := strings.CutPrefix(line, "ref: ")
+		if !ok {
+			continue
+		}
+		target, _, _ = strings.Cut(target, "\t")
+		return plumbing.ReferenceName(target), nil
+	}
+	return "", nil
+}
+
+// followRemoteHead points local HEAD to the same branch as remote's HEAD,
+// so renames of upstream default branch are picked up.
+func (g *Repo) followRemoteHead(target plumbing.ReferenceName) error {
+	if target == "" {
+		return nil
+	}
+
+	if _, err := g.r.Storer.Reference(target); err != nil {
+		// upstream's default branch is filtered out, keep HEAD as is
+		return nil
+	}
+
+	head, err := g.r.Storer.Reference(plumbing.HEAD)
+	if err == nil && head.Type() == plumbing.SymbolicReference && head.Target() == target {
+		return nil
+	}
+
+	if err := g.r.Storer.SetReference(
+		plumbing.NewSymbolicReference(plumbing.HEAD, target),
+	); err != nil {
+		return fmt.Errorf("failed to set HEAD: %w", err)
+	}
+	return nil
+}
+
+// pruneFiltered removes local refs that don't pass the filter, git only
+// prunes refs that are gone from upstream.
+func (g *Repo) pruneFiltered(filter RefFilter) error {
+	iter, err := g.r.References()
+	if err != nil {
+		return fmt.Errorf("failed to list local references: %w", err)
+	}
+
+	var stale []plumbing.ReferenceName
+	if err := iter.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		if name != plumbing.HEAD && !filter.Match(name.String()) {
+			stale = append(stale, name)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, name := range stale {
+		if err := g.r.Storer.RemoveReference(name); err != nil {
+			return fmt.Errorf("failed to prune %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// refsSnapshot returns all local refs, with the target of HEAD, as a map of
+// ref name to what it points to.
+func (g *Repo) refsSnapshot() (map[string]string, error) {
+	iter, err := g.r.Storer.IterReferences()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local references: %w", err)
+	}
+
+	out := make(map[string]string)
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.SymbolicReference {
+			out[ref.Name().String()] = ref.Target().String()
+		} else {
+			out[ref.Name().String()] = ref.Hash().String()
+		}
+		return nil
+	})
+	return out, err
+}
+
+// fetchEnv returns environment for git talking to the remote. Config is
+// passed through the environment, so the token doesn't show up in process list.
+func fetchEnv(githubToken string) []string {
+	cfg := slices.Clone(fetchMemoryConfig)
+	if githubToken != "" {
+		creds := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + githubToken))
+		cfg = append(cfg, [2]string{"http.extraHeader", "Authorization: Basic " + creds})
+	}
+
+	env := []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT=" + strconv.Itoa(len(cfg)),
+	}
+	for i, kv := range cfg {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]),
+		)
+	}
+	return env
+}
+
+// fetchGit runs git command that talks to the remote. Stderr is streamed
+// to progress line by line, last line of it ends up in the error.
+func (g *Repo) fetchGit(ctx context.Context, env []string, stdout, progress io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.path
+	cmd.Env = slices.Concat(gitEnv, env)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// git spawns helpers (remote-https, index-pack), kill all of them on cancel
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = 10 * time.Second
+	cmd.Stdout = cmp.Or(stdout, io.Discard)
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var lastLine string
+	sc := bufio.NewScanner(stderr)
+	sc.Split(scanProgressLines)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		lastLine = line
+		if progress != nil {
+			_, _ = fmt.Fprintln(progress, line)
+		}
+	}
+	// drain the rest if scanner gave up on a too long line
+	_, _ = io.Copy(io.Discard, stderr)
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("%w, stderr: %s", err, lastLine)
+	}
+	return nil
+}
+
+// scanProgressLines is [bufio.ScanLines] that also splits on "\r",
+// which git uses to redraw progress lines.
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestRefFilter_refSpecs(t *testing.T) {
+	is.Equal(t, RefFilter{}.refSpecs(), []string{"+refs/*:refs/*"})
+	is.Equal(t, RefFilter{
+		Include: []string{"refs/heads/*", "refs/tags/v*"},
+		Exclude: []string{"refs/heads/wip/*"},
+	}.refSpecs(), []string{
+		"+refs/heads/*:refs/heads/*",
+		"+refs/tags/v*:refs/tags/v*",
+		"^refs/heads/wip/*",
+	})
+}
+
+func TestRepo_FetchNewCommits(t *testing.T) {
+	upstream := newTestRepo(t)
+	upstream.commitFile("README.md", "# Test", "Initial commit")
+
+	mirror := newMirror(t, upstream)
+	_, err := mirror.Fetch(t.Context(), FetchOptions{})
+	is.Err(t, err, nil)
+
+	h := upstream.commitFile("README.md", "# Test 2", "Second commit")
+
+	var progress strings.Builder
+	isUpdated, err := mirror.Fetch(t.Context(), FetchOptions{Progress: &progress})
+	is.Err(t, err, nil)
+	is.Equal(t, isUpdated, true)
+	is.Equal(t, progress.Len() > 0, true)
+
+	ref, err := mirror.r.Reference("refs/heads/master", false)
+	is.Err(t, err, nil)
+	is.Equal(t, ref.Hash(), h)
+}
+
+func TestRepo_FetchCanceled(t *testing.T) {
+	upstream := newTestRepo(t)
+	upstream.commitFile("README.md", "# Test", "Initial commit")
+	mirror := newMirror(t, upstream)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := mirror.Fetch(ctx, FetchOptions{})
+	is.Err(t, err, context.Canceled)
+}
+
+func TestFetchEnv(t *testing.T) {
+	env := fetchEnv("secret")
+	is.Equal(t, env[1], "GIT_CONFIG_COUNT=6")
+	is.Equal(t, strings.HasPrefix(env[len(env)-1], "GIT_CONFIG_VALUE_5=Authorization: Basic "), true)
+
+	env = fetchEnv("")
+	is.Equal(t, env[1], "GIT_CONFIG_COUNT=5")
+}
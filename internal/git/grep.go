@@ -0,0 +1,176 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// grepTimeout is max duration of git grep, so a search over a huge tree,
+	// or with a pathological regexp, can't keep the server busy.
+	grepTimeout = 10 * time.Second
+
+	// GrepMaxMatches is max number of matched lines returned by [Repo.Grep].
+	GrepMaxMatches = 500
+
+	// grepMaxLineLen is max length of matched line, longer lines (usually
+	// minified files) are cut.
+	grepMaxLineLen = 512
+)
+
+var ErrInvalidPattern = errors.New("invalid search pattern")
+
+type GrepOptions struct {
+	Pattern   string
+	Regexp    bool // extended regexp, literal string otherwise
+	MatchCase bool
+
+	// Paths limits search to files matching any of the globs, e.g. "*.go".
+	Paths []string
+
+	// MaxMatches defaults to [GrepMaxMatches], and can't exceed it.
+	MaxMatches int
+}
+
+type GrepMatch struct {
+	Line    int
+	Content string
+}
+
+type GrepFile struct {
+	Path    string
+	Matches []GrepMatch
+}
+
+type GrepResult struct {
+	Files []GrepFile
+
+	// Truncated is set when search hit max matches or timed out.
+	Truncated bool
+	TimedOut  bool
+}
+
+// Grep searches the tree for lines matching the pattern. Binary files are skipped.
+func (g *Repo) Grep(ctx context.Context, opts GrepOptions) (*GrepResult, error) {
+	res := &GrepResult{}
+	if g.IsEmpty() || opts.Pattern == "" {
+		return res, nil
+	}
+
+	maxMatches := opts.MaxMatches
+	if maxMatches <= 0 || maxMatches > GrepMaxMatches {
+		maxMatches = GrepMaxMatches
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, grepTimeout)
+	defer cancel()
+
+	rev := g.h.String()
+	args := []string{"grep", "--null", "--line-number", "-I", "--no-color"}
+	if opts.Regexp {
+		args = append(args, "--extended-regexp")
+	} else {
+		args = append(args, "--fixed-strings")
+	}
+	if !opts.MatchCase {
+		args = append(args, "--ignore-case")
+	}
+	args = append(args, "-e", opts.Pattern, rev, "--")
+	for _, p := range opts.Paths {
+		// pathspec magic could be used to look outside of the tree
+		if p = strings.TrimSpace(p); p != "" && !strings.HasPrefix(p, ":") {
+			args = append(args, p)
+		}
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.path
+	cmd.Env = gitEnv
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var matches int
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for sc.Scan() {
+		if matches == maxMatches {
+			res.Truncated = true
+			break
+		}
+
+		// <rev>:<path>\0<line>\0<content>
+		fpath, rest, ok := strings.Cut(strings.TrimPrefix(sc.Text(), rev+":"), "\x00")
+		if !ok {
+			continue
+		}
+		lineNo, content, ok := strings.Cut(rest, "\x00")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(lineNo)
+		if err != nil {
+			continue
+		}
+		if len(content) > grepMaxLineLen {
+			content = strings.ToValidUTF8(content[:grepMaxLineLen], "")
+		}
+
+		if l := len(res.Files); l == 0 || res.Files[l-1].Path != fpath {
+			res.Files = append(res.Files, GrepFile{Path: fpath})
+		}
+		f := &res.Files[len(res.Files)-1]
+		f.Matches = append(f.Matches, GrepMatch{Line: n, Content: content})
+		matches++
+	}
+
+	scanErr := sc.Err()
+	if res.Truncated || scanErr != nil {
+		cancel() // stop git, nobody reads the rest of the output
+	}
+
+	err = cmd.Wait()
+	switch {
+	case scanErr != nil:
+		return nil, fmt.Errorf("reading grep output: %w", scanErr)
+	case res.Truncated:
+		return res, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.Truncated, res.TimedOut = true, true
+		return res, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	}
+
+	if exitErr, ok := errors.AsType[*exec.ExitError](err); ok {
+		switch exitErr.ExitCode() {
+		case 1: // nothing matched
+			return res, nil
+		case 128:
+			// 128 is also returned for broken repos, or bad paths, but only
+			// regexp errors are reported as "-e option, '<pattern>': <error>"
+			msg := strings.TrimSpace(stderr.String())
+			if msg, ok := strings.CutPrefix(msg, "fatal: -e option, "); ok {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidPattern, msg)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("grep: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestRepo_Grep(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("main.go", "package main\n\nfunc main() {\n\tprintln(\"Hello\")\n}\n", "Add main.go")
+	r.commitFile("docs/README.md", "# hello\n\nsay hello(\n", "Add readme")
+	r.commitFile("image.bin", "hello\x00world", "Add binary")
+	repo := r.open()
+
+	t.Run("literal, ignores case", func(t *testing.T) {
+		res, err := repo.Grep(t.Context(), GrepOptions{Pattern: "hello("})
+		is.Err(t, err, nil)
+		is.Equal(t, res.Files, []GrepFile{
+			{Path: "docs/README.md", Matches: []GrepMatch{{Line: 3, Content: "say hello("}}},
+		})
+	})
+
+	t.Run("match case", func(t *testing.T) {
+		res, err := repo.Grep(t.Context(), GrepOptions{Pattern: "Hello", MatchCase: true})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Files), 1)
+		is.Equal(t, res.Files[0].Path, "main.go")
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		res, err := repo.Grep(t.Context(), GrepOptions{Pattern: "^(package|func) ", Regexp: true})
+		is.Err(t, err, nil)
+		is.Equal(t, res.Files, []GrepFile{{Path: "main.go", Matches: []GrepMatch{
+			{Line: 1, Content: "package main"},
+			{Line: 3, Content: "func main() {"},
+		}}})
+	})
+
+	t.Run("invalid regexp", func(t *testing.T) {
+		_, err := repo.Grep(t.Context(), GrepOptions{Pattern: "(", Regexp: true})
+		is.Err(t, err, ErrInvalidPattern)
+	})
+
+	t.Run("failure that isn't invalid pattern", func(t *testing.T) {
+		_, err := repo.Grep(t.Context(), GrepOptions{Pattern: "hello", Paths: []string{"../outside"}})
+		is.Err(t, err, "outside repository")
+		is.Equal(t, errors.Is(err, ErrInvalidPattern), false)
+	})
+
+	t.Run("path glob", func(t *testing.T) {
+		res, err := repo.Grep(t.Context(), GrepOptions{Pattern: "hello", Paths: []string{"*.md"}})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Files), 1)
+		is.Equal(t, res.Files[0].Path, "docs/README.md")
+	})
+
+	t.Run("max matches", func(t *testing.T) {
+		res, err := repo.Grep(t.Context(), GrepOptions{Pattern: "hello", MaxMatches: 2})
+		is.Err(t, err, nil)
+		is.Equal(t, res.Truncated, true)
+		is.Equal(t, len(res.Files[0].Matches), 2)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		res, err := repo.Grep(t.Context(), GrepOptions{Pattern: "nonexistent"})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Files), 0)
+		is.Equal(t, res.Truncated, false)
+	})
+}
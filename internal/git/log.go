@@ -0,0 +1,161 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// logTimeout is max duration of git log, filtered logs of long histories
+// are slow, since git walks all of it to find matches.
+const logTimeout = 10 * time.Second
+
+// LogQuery filters commits returned by [Repo.Log]. Author, Committer, and
+// Grep are case-insensitive substrings.
+type LogQuery struct {
+	Path        string // file or directory, renames are followed for files
+	After       string // cursor, hash of the last commit on previous page
+	Author      string
+	Committer   string
+	Grep        string // matched against commit message
+	Since       time.Time
+	Until       time.Time
+	FirstParent bool
+	NoMerges    bool
+}
+
+func (q LogQuery) args() []string {
+	args := []string{"--format=%H"}
+	if q.Author != "" {
+		args = append(args, "--author="+q.Author)
+	}
+	if q.Committer != "" {
+		args = append(args, "--committer="+q.Committer)
+	}
+	if q.Grep != "" {
+		args = append(args, "--grep="+q.Grep)
+	}
+	if q.Author != "" || q.Committer != "" || q.Grep != "" {
+		args = append(args, "--regexp-ignore-case", "--fixed-strings")
+	}
+	if !q.Since.IsZero() {
+		args = append(args, "--since="+q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--until="+q.Until.Format(time.RFC3339))
+	}
+	if q.FirstParent {
+		args = append(args, "--first-parent")
+	}
+	if q.NoMerges {
+		args = append(args, "--no-merges")
+	}
+	return args
+}
+
+// Log returns [CommitsPage] commits matching the query, starting from HEAD.
+func (g *Repo) Log(ctx context.Context, q LogQuery) ([]*Commit, error) {
+	if g.IsEmpty() {
+		return []*Commit{}, nil
+	}
+
+	if q.After != "" && !isHex(q.After) {
+		return nil, fmt.Errorf("invalid cursor: %s", q.After)
+	}
+
+	args := append([]string{"log"}, g.h.String())
+	args = append(args, q.args()...)
+	if q.Path != "" {
+		q.Path = path.Clean(q.Path)
+		isFile, err := g.IsFile(q.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// cursor is looked up by walking from HEAD, instead of starting log
+		// at it, because file could have had different name at that commit.
+		if isFile {
+			args = append(args, "--follow")
+		} else if q.After != "" {
+			args[1] = q.After
+		}
+		args = append(args, "--", q.Path)
+	} else if q.After != "" {
+		args[1] = q.After
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, logTimeout)
+	output, err := g.streamingGit(ctx, args...)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("log: %w", err)
+	}
+	// git is killed before waiting for it, with filters it could still be
+	// walking history without writing anything
+	defer func() {
+		cancel()
+		_ = output.Close()
+	}()
+
+	commits := make([]*Commit, 0, CommitsPage)
+	seenCursor := q.After == ""
+	sc := bufio.NewScanner(output)
+	for sc.Scan() && len(commits) < CommitsPage {
+		hash := sc.Text()
+		if !seenCursor {
+			// cursor is usually a short hash
+			seenCursor = strings.HasPrefix(hash, q.After)
+			continue
+		}
+
+		c, err := g.r.CommitObject(plumbing.NewHash(hash))
+		if err != nil {
+			return nil, fmt.Errorf("commit object %s: %w", hash, err)
+		}
+		commits = append(commits, newCommit(c))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading log: %w", err)
+	}
+	if len(commits) < CommitsPage && ctx.Err() != nil {
+		return nil, fmt.Errorf("log: %w", ctx.Err())
+	}
+	if !seenCursor {
+		return nil, fmt.Errorf("invalid cursor: %s", q.After)
+	}
+
+	return commits, nil
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFile reports whether the path is a file, not a directory or a submodule.
+func (g *Repo) IsFile(fpath string) (bool, error) {
+	c, err := g.r.CommitObject(g.h)
+	if err != nil {
+		return false, fmt.Errorf("commit object: %w", err)
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return false, fmt.Errorf("file tree: %w", err)
+	}
+
+	entry, err := tree.FindEntry(fpath)
+	if err != nil {
+		return false, ErrFileNotFound
+	}
+	return entry.Mode.IsFile(), nil
+}
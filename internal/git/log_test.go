@@ -0,0 +1,169 @@
+package git
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"olexsmir.xyz/x/is"
+)
+
+func TestRepo_Log_path(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commitFile("old.go", "package main\n", "Add old.go")
+	r.commitFile("other.go", "package other\n", "Add other.go")
+	r.commitFile("dir/a.go", "package dir\n", "Add dir/a.go")
+
+	wt, err := r.r.Worktree()
+	is.Err(t, err, nil)
+	_, err = wt.Move("old.go", "new.go")
+	is.Err(t, err, nil)
+	renamed, err := wt.Commit("Rename old.go to new.go", &git.CommitOptions{})
+	is.Err(t, err, nil)
+
+	r.commitFile("dir/b.go", "package dir\n", "Add dir/b.go")
+
+	repo := r.open()
+
+	t.Run("follows renames of file", func(t *testing.T) {
+		commits, err := repo.Log(t.Context(), LogQuery{Path: "new.go"})
+		is.Err(t, err, nil)
+		is.Equal(t, len(commits), 2)
+		is.Equal(t, commits[0].Hash, renamed.String())
+		is.Equal(t, commits[1].Hash, first.String())
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		commits, err := repo.Log(t.Context(), LogQuery{Path: "dir"})
+		is.Err(t, err, nil)
+		is.Equal(t, len(commits), 2)
+		is.Equal(t, commits[0].Message, "Add dir/b.go")
+	})
+
+	t.Run("after cursor", func(t *testing.T) {
+		commits, err := repo.Log(t.Context(), LogQuery{Path: "new.go", After: renamed.String()[:7]})
+		is.Err(t, err, nil)
+		is.Equal(t, len(commits), 1)
+		is.Equal(t, commits[0].Hash, first.String())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := repo.Log(t.Context(), LogQuery{Path: "new.go", After: "deadbeef"})
+		is.Err(t, err, "invalid cursor")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := repo.Log(t.Context(), LogQuery{Path: "nonexistent"})
+		is.Err(t, err, ErrFileNotFound)
+	})
+}
+
+func TestRepo_Log(t *testing.T) {
+	r := newTestRepo(t)
+	march := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	r.commitFileAt("a", "a", "Initial commit", march.AddDate(0, -1, 0))
+	r.commitFileAt("b", "b", "Fix the parser", march)
+	r.commitFileAt("c", "c", "Add feature", march.AddDate(0, 0, 1))
+
+	r.checkoutBranch("side", true)
+	side := r.commitFileAt("d", "d", "Side work", march.AddDate(0, 1, 0))
+	r.checkoutBranch("master", false)
+	head := r.commitFileAt("e", "e", "Update docs", march.AddDate(0, 1, 0))
+
+	wt, err := r.r.Worktree()
+	is.Err(t, err, nil)
+	merge, err := wt.Commit("Merge side, fix typo", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Parents:           []plumbing.Hash{head, side},
+		Author: &object.Signature{
+			Name:  "Jane Doe",
+			Email: "jane@example.com",
+			When:  march.AddDate(0, 1, 1),
+		},
+	})
+	is.Err(t, err, nil)
+
+	repo := r.open()
+	messages := func(q LogQuery) []string {
+		t.Helper()
+		commits, err := repo.Log(t.Context(), q)
+		is.Err(t, err, nil)
+		out := make([]string, len(commits))
+		for i, c := range commits {
+			out[i] = c.Message
+		}
+		return out
+	}
+
+	t.Run("no filter", func(t *testing.T) {
+		is.Equal(t, len(messages(LogQuery{})), 6)
+	})
+
+	t.Run("author", func(t *testing.T) {
+		is.Equal(t, messages(LogQuery{Author: "JANE"}), []string{"Merge side, fix typo"})
+	})
+
+	t.Run("committer", func(t *testing.T) {
+		is.Equal(t, len(messages(LogQuery{Committer: "test@test.local"})), 5)
+	})
+
+	t.Run("grep", func(t *testing.T) {
+		is.Equal(t, messages(LogQuery{Grep: "fix"}), []string{"Merge side, fix typo", "Fix the parser"})
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		is.Equal(t, messages(LogQuery{
+			Since: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			Until: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		}), []string{"Add feature", "Fix the parser"})
+	})
+
+	t.Run("no merges", func(t *testing.T) {
+		commits := messages(LogQuery{NoMerges: true})
+		is.Equal(t, len(commits), 5)
+		is.Equal(t, slices.Contains(commits, "Merge side, fix typo"), false)
+	})
+
+	t.Run("first parent", func(t *testing.T) {
+		commits := messages(LogQuery{FirstParent: true})
+		is.Equal(t, len(commits), 5)
+		is.Equal(t, slices.Contains(commits, "Side work"), false)
+	})
+
+	t.Run("after cursor", func(t *testing.T) {
+		is.Equal(t, messages(LogQuery{Grep: "fix", After: merge.String()[:7]}), []string{"Fix the parser"})
+	})
+
+	t.Run("cursor must be a hash", func(t *testing.T) {
+		_, err := repo.Log(t.Context(), LogQuery{After: "--output=/tmp/x"})
+		is.Err(t, err, "invalid cursor")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err := repo.Log(ctx, LogQuery{Grep: "nothing matches"})
+		is.Err(t, err, context.Canceled)
+	})
+}
+
+func TestRepo_IsFile(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("docs/guide.md", "# guide\n", "Add guide")
+	repo := r.open()
+
+	isFile, err := repo.IsFile("docs/guide.md")
+	is.Err(t, err, nil)
+	is.Equal(t, isFile, true)
+
+	isFile, err = repo.IsFile("docs")
+	is.Err(t, err, nil)
+	is.Equal(t, isFile, false)
+
+	_, err = repo.IsFile("nope.md")
+	is.Err(t, err, ErrFileNotFound)
+}
@@ -20,12 +20,9 @@ func (g *Repo) InfoRefs(ctx context.Context, protocol string, out io.Writer) err
 
 	if err := g.gitCmd(ctx, cmdOpts{
 		GitProtocol: protocol,
-		Cmd: []string{
-			"-c", "uploadpack.allowFilter=true",
-			"upload-pack", "--stateless-rpc", "--advertise-refs",
-		},
-		Stdout: out,
-		Stderr: io.Discard,
+		Cmd:         g.uploadPackCmd("--stateless-rpc", "--advertise-refs"),
+		Stdout:      out,
+		Stderr:      io.Discard,
 	}); err != nil {
 		return fmt.Errorf("git-upload-pack: %w", err)
 	}
@@ -35,7 +32,7 @@ func (g *Repo) InfoRefs(ctx context.Context, protocol string, out io.Writer) err
 // UploadPack executes git-upload-pack for smart-HTTP git fetch/clone.
 // StatelessRPC should be true in case it's used over http, and false for ssh.
 func (g *Repo) UploadPack(ctx context.Context, statelessRPC bool, protocol string, in io.Reader, out io.Writer) error {
-	cmd := []string{"-c", "uploadpack.allowFilter=true", "upload-pack"}
+	cmd := g.uploadPackCmd()
 	if statelessRPC {
 		cmd = append(cmd, "--stateless-rpc")
 	}
@@ -52,6 +49,21 @@ func (g *Repo) UploadPack(ctx context.Context, statelessRPC bool, protocol strin
 	return nil
 }
 
+// HideRefs sets ref prefixes that are never advertised by [Repo.InfoRefs] and
+// [Repo.UploadPack], same as git's uploadpack.hideRefs.
+func (g *Repo) HideRefs(prefixes []string) {
+	g.hiddenRefs = prefixes
+}
+
+func (g *Repo) uploadPackCmd(args ...string) []string {
+	cmd := []string{"-c", "uploadpack.allowFilter=true"}
+	for _, ref := range g.hiddenRefs {
+		cmd = append(cmd, "-c", "uploadpack.hideRefs="+ref)
+	}
+	cmd = append(cmd, "upload-pack")
+	return append(cmd, args...)
+}
+
 // ReceivePack executes git-receive-pack for git push.
 func (g *Repo) ReceivePack(ctx context.Context, in io.Reader, out, errout io.Writer) error {
 	if err := g.gitCmd(ctx, cmdOpts{
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Patch writes the commit as an email with its patch, which can be applied
+// with git am. Merge commits are formatted with changes against their first
+// parent.
+func (g *Repo) Patch(ctx context.Context, w io.Writer) error {
+	return g.copyGit(ctx, w, "log", "-1", "--format=email", "--patch-with-stat",
+		"--diff-merges=first-parent", "-M", "--no-color", "--no-ext-diff", g.h.String())
+}
+
+// RawDiff writes changes of the commit against its first parent, as printed
+// by git diff.
+func (g *Repo) RawDiff(ctx context.Context, w io.Writer) error {
+	return g.copyGit(ctx, w, "log", "-1", "--format=", "--patch",
+		"--diff-merges=first-parent", "-M", "--no-color", "--no-ext-diff", g.h.String())
+}
+
+// PatchSeries writes commits of the range's head, which aren't in its base,
+// as a series of emails in mbox format, same as git format-patch. Merge
+// commits are skipped.
+func (g *Repo) PatchSeries(ctx context.Context, rng Range, w io.Writer) error {
+	base, head, err := g.resolveRange(rng.Base, rng.Head)
+	if err != nil {
+		return err
+	}
+	return g.copyGit(ctx, w, "format-patch", "--stdout", "-M", "--no-color",
+		fmt.Sprintf("%s..%s", base, head))
+}
+
+// RawDiffBetween writes changes of the range, as printed by git diff.
+func (g *Repo) RawDiffBetween(ctx context.Context, rng Range, w io.Writer) error {
+	base, head, err := g.resolveRange(rng.Base, rng.Head)
+	if err != nil {
+		return err
+	}
+	rng.Base, rng.Head = base.String(), head.String()
+	return g.copyGit(ctx, w, "diff", "-M", "--no-color", "--no-ext-diff", rng.String())
+}
+
+// copyGit runs git command, and copies its stdout to w.
+func (g *Repo) copyGit(ctx context.Context, w io.Writer, args ...string) error {
+	rc, err := g.streamingGit(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, rc)
+	if cerr := rc.Close(); cerr != nil {
+		return errors.Join(err, fmt.Errorf("git %s: %w", args[0], cerr))
+	}
+	return err
+}
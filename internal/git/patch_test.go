@@ -0,0 +1,128 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestRepo_Patch(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("README.md", "# Test\n", "Initial commit")
+	r.commitFile("hello.txt", "hello\n", "Add hello")
+
+	t.Run("patch", func(t *testing.T) {
+		var buf bytes.Buffer
+		is.Err(t, r.open().Patch(t.Context(), &buf), nil)
+		out := buf.String()
+		is.Equal(t, strings.HasPrefix(out, "From "), true)
+		is.Equal(t, strings.Contains(out, "Subject: [PATCH] Add hello\n"), true)
+		is.Equal(t, strings.Contains(out, " hello.txt | 1 +\n"), true)
+		is.Equal(t, strings.Contains(out, "+hello\n"), true)
+	})
+
+	t.Run("applies with git am", func(t *testing.T) {
+		var buf bytes.Buffer
+		is.Err(t, r.open().Patch(t.Context(), &buf), nil)
+
+		dir := applyPatch(t, buf.Bytes())
+		content, err := os.ReadFile(filepath.Join(dir, "hello.txt"))
+		is.Err(t, err, nil)
+		is.Equal(t, string(content), "hello\n")
+	})
+
+	t.Run("ignores host git config", func(t *testing.T) {
+		hostileGitConfig(t)
+
+		var buf bytes.Buffer
+		is.Err(t, r.open().Patch(t.Context(), &buf), nil)
+		out := buf.String()
+		is.Equal(t, strings.Contains(out, "diff --git a/hello.txt b/hello.txt\n"), true)
+		is.Equal(t, strings.Contains(out, "ACME-CORP"), false)
+
+		dir := applyPatch(t, buf.Bytes())
+		content, err := os.ReadFile(filepath.Join(dir, "hello.txt"))
+		is.Err(t, err, nil)
+		is.Equal(t, string(content), "hello\n")
+	})
+
+	t.Run("raw diff", func(t *testing.T) {
+		var buf bytes.Buffer
+		is.Err(t, r.open().RawDiff(t.Context(), &buf), nil)
+		out := buf.String()
+		is.Equal(t, strings.HasPrefix(out, "diff --git a/hello.txt b/hello.txt\n"), true)
+		is.Equal(t, strings.Contains(out, "Subject:"), false)
+	})
+}
+
+func TestRepo_PatchSeries(t *testing.T) {
+	r := newTestRepo(t)
+	r.createTag("v1.0", r.commitFile("README.md", "# Test\n", "Initial commit"))
+	r.commitFile("a.txt", "a\n", "Add a")
+	r.commitFile("b.txt", "b\n", "Add b")
+
+	t.Run("series", func(t *testing.T) {
+		var buf bytes.Buffer
+		is.Err(t, r.open().PatchSeries(t.Context(), Range{Base: "v1.0", Head: "master"}, &buf), nil)
+		out := buf.String()
+		is.Equal(t, strings.Count(out, "\nFrom: "), 2)
+		is.Equal(t, strings.Contains(out, "Subject: [PATCH 1/2] Add a\n"), true)
+		is.Equal(t, strings.Contains(out, "Subject: [PATCH 2/2] Add b\n"), true)
+	})
+
+	t.Run("raw diff", func(t *testing.T) {
+		var buf bytes.Buffer
+		is.Err(t, r.open().RawDiffBetween(t.Context(), Range{Base: "v1.0", Head: "master"}, &buf), nil)
+		out := buf.String()
+		is.Equal(t, strings.Count(out, "diff --git "), 2)
+		is.Equal(t, strings.Contains(out, "+a\n"), true)
+		is.Equal(t, strings.Contains(out, "+b\n"), true)
+	})
+
+	t.Run("series ignores host git config", func(t *testing.T) {
+		hostileGitConfig(t)
+
+		var buf bytes.Buffer
+		is.Err(t, r.open().PatchSeries(t.Context(), Range{Base: "v1.0", Head: "master"}, &buf), nil)
+		out := buf.String()
+		is.Equal(t, strings.Contains(out, "[PATCH 0/2]"), false)
+		is.Equal(t, strings.Contains(out, "ACME-CORP"), false)
+
+		dir := applyPatch(t, buf.Bytes())
+		_, err := os.Stat(filepath.Join(dir, "b.txt"))
+		is.Err(t, err, nil)
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		var buf bytes.Buffer
+		is.Err(t, r.open().PatchSeries(t.Context(), Range{Base: "does-not-exist", Head: "master"}, &buf), "resolving base ref")
+		is.Equal(t, buf.Len(), 0)
+	})
+}
+
+// applyPatch applies patch with git am to a new repo, which has README.md of
+// the test repos' first commit, and returns its path.
+func applyPatch(t *testing.T, patch []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitCmd := func(stdin []byte, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		cmd.Stdin = bytes.NewReader(stdin)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	gitCmd(nil, "init", "-q")
+	is.Err(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test\n"), 0o644), nil)
+	gitCmd(nil, "add", ".")
+	gitCmd(nil, "commit", "-qm", "init")
+	gitCmd(patch, "am", "-q")
+	return dir
+}
@@ -0,0 +1,44 @@
+package git
+
+import "strings"
+
+// RefFilter selects which refs are mirrored from upstream.
+//
+// Patterns are full ref names (e.g. "refs/heads/main"), and may contain a
+// single "*" that matches any sequence of characters, including "/", the
+// same way as in git refspecs. Empty Include means all refs.
+type RefFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Match reports whether ref is included, and not excluded by the filter.
+func (f RefFilter) Match(ref string) bool {
+	for _, pattern := range f.Exclude {
+		if matchRefPattern(pattern, ref) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.Include {
+		if matchRefPattern(pattern, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRefPattern(pattern, ref string) bool {
+	prefix, suffix, found := strings.Cut(pattern, "*")
+	if !found {
+		return pattern == ref
+	}
+
+	return len(ref) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(ref, prefix) &&
+		strings.HasSuffix(ref, suffix)
+}
@@ -0,0 +1,196 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestRefFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter RefFilter
+		ref    string
+		want   bool
+	}{
+		{name: "empty filter matches all", ref: "refs/pull/1/head", want: true},
+		{
+			name:   "include wildcard",
+			filter: RefFilter{Include: []string{"refs/heads/*"}},
+			ref:    "refs/heads/feature/new",
+			want:   true,
+		},
+		{
+			name:   "not included",
+			filter: RefFilter{Include: []string{"refs/heads/*"}},
+			ref:    "refs/tags/v1.0.0",
+			want:   false,
+		},
+		{
+			name:   "exact include",
+			filter: RefFilter{Include: []string{"refs/heads/main"}},
+			ref:    "refs/heads/main",
+			want:   true,
+		},
+		{
+			name:   "exact include doesn't match prefix",
+			filter: RefFilter{Include: []string{"refs/heads/main"}},
+			ref:    "refs/heads/main2",
+			want:   false,
+		},
+		{
+			name:   "excluded",
+			filter: RefFilter{Exclude: []string{"refs/pull/*"}},
+			ref:    "refs/pull/42/head",
+			want:   false,
+		},
+		{
+			name:   "exclude wins over include",
+			filter: RefFilter{Include: []string{"refs/*"}, Exclude: []string{"refs/heads/wip-*"}},
+			ref:    "refs/heads/wip-thing",
+			want:   false,
+		},
+		{
+			name:   "wildcard in the middle",
+			filter: RefFilter{Exclude: []string{"refs/pull/*/merge"}},
+			ref:    "refs/pull/42/head",
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is.Equal(t, tt.filter.Match(tt.ref), tt.want)
+		})
+	}
+}
+
+func TestRepo_MirrorRefFilter(t *testing.T) {
+	t.Run("empty by default", func(t *testing.T) {
+		f, err := newTestRepo(t).open().MirrorRefFilter()
+		is.Err(t, err, nil)
+		is.Equal(t, len(f.Include), 0)
+		is.Equal(t, len(f.Exclude), 0)
+	})
+
+	t.Run("set replaces previous filter", func(t *testing.T) {
+		r := newTestRepo(t).open()
+		is.Err(t, r.SetMirrorRefFilter(RefFilter{Include: []string{"refs/*"}}), nil)
+
+		want := RefFilter{
+			Include: []string{"refs/heads/*", "refs/tags/*"},
+			Exclude: []string{"refs/heads/wip-*"},
+		}
+		is.Err(t, r.SetMirrorRefFilter(want), nil)
+
+		got, err := r.MirrorRefFilter()
+		is.Err(t, err, nil)
+		is.Equal(t, got, want)
+	})
+}
+
+func TestRepo_Fetch(t *testing.T) {
+	t.Run("mirrors all refs", func(t *testing.T) {
+		upstream := newTestRepo(t)
+		h := upstream.commitFile("README.md", "# Test", "Initial commit")
+		upstream.createTag("v1.0.0", h)
+		upstream.createBranch("feature", h)
+
+		mirror := newMirror(t, upstream)
+		isUpdated, err := mirror.Fetch(t.Context(), FetchOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, isUpdated, true)
+
+		branches, err := mirror.Branches()
+		is.Err(t, err, nil)
+		is.Equal(t, len(branches), 2)
+
+		tags, err := mirror.Tags()
+		is.Err(t, err, nil)
+		is.Equal(t, len(tags), 1)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		upstream := newTestRepo(t)
+		upstream.commitFile("README.md", "# Test", "Initial commit")
+
+		mirror := newMirror(t, upstream)
+		_, err := mirror.Fetch(t.Context(), FetchOptions{})
+		is.Err(t, err, nil)
+
+		isUpdated, err := mirror.Fetch(t.Context(), FetchOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, isUpdated, false)
+	})
+
+	t.Run("applies refs filter and prunes", func(t *testing.T) {
+		upstream := newTestRepo(t)
+		h := upstream.commitFile("README.md", "# Test", "Initial commit")
+		upstream.createBranch("pull-base", h)
+		upstream.createTag("v1.0.0", h)
+
+		mirror := newMirror(t, upstream)
+		_, err := mirror.Fetch(t.Context(), FetchOptions{})
+		is.Err(t, err, nil)
+
+		is.Err(t, mirror.SetMirrorRefFilter(RefFilter{
+			Include: []string{"refs/heads/*"},
+			Exclude: []string{"refs/heads/pull-*"},
+		}), nil)
+
+		isUpdated, err := mirror.Fetch(t.Context(), FetchOptions{})
+		is.Err(t, err, nil)
+		is.Equal(t, isUpdated, true)
+
+		m := &testRepo{tb: t, r: mirror.r}
+		is.Equal(t, m.hasRef("refs/heads/master"), true)
+		is.Equal(t, m.hasRef("refs/heads/pull-base"), false)
+		is.Equal(t, m.hasRef("refs/tags/v1.0.0"), false)
+	})
+}
+
+func TestRepo_FetchFollowsRemoteHead(t *testing.T) {
+	upstream := newTestRepo(t)
+	upstream.commitFile("README.md", "# Test", "Initial commit")
+
+	mirror := newMirror(t, upstream)
+	_, err := mirror.Fetch(t.Context(), FetchOptions{})
+	is.Err(t, err, nil)
+
+	branch, err := mirror.DefaultBranch()
+	is.Err(t, err, nil)
+	is.Equal(t, branch, "master")
+
+	// upstream renames master to main
+	upstream.checkoutBranch("main", true)
+	is.Err(t, upstream.r.Storer.RemoveReference(plumbing.NewBranchReferenceName("master")), nil)
+
+	isUpdated, err := mirror.Fetch(t.Context(), FetchOptions{})
+	is.Err(t, err, nil)
+	is.Equal(t, isUpdated, true)
+
+	branch, err = mirror.DefaultBranch()
+	is.Err(t, err, nil)
+	is.Equal(t, branch, "main")
+
+	isUpdated, err = mirror.Fetch(t.Context(), FetchOptions{})
+	is.Err(t, err, nil)
+	is.Equal(t, isUpdated, false)
+}
+
+func TestRepo_HideRefs(t *testing.T) {
+	r := newTestRepo(t)
+	h := r.commitFile("README.md", "# Test", "Initial commit")
+	r.createBranch("internal/thing", h)
+
+	repo := r.open()
+	repo.HideRefs([]string{"refs/heads/internal"})
+
+	var out strings.Builder
+	is.Err(t, repo.InfoRefs(t.Context(), "", &out), nil)
+	is.Equal(t, strings.Contains(out.String(), "refs/heads/master"), true)
+	is.Equal(t, strings.Contains(out.String(), "refs/heads/internal/thing"), false)
+}
@@ -13,8 +13,6 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
-	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
 // Thanks https://git.icyphox.sh/legit/blob/master/git/git.go
@@ -22,6 +20,7 @@ import (
 var (
 	ErrEmptyRepo    = errors.New("repository has no commits")
 	ErrFileNotFound = errors.New("file not found")
+	ErrNoParent     = errors.New("commit has no such parent")
 	ErrPrivate      = errors.New("repository is private")
 	ErrRepoNotFound = errors.New("repository not found")
 )
@@ -30,6 +29,8 @@ type Repo struct {
 	path string
 	r    *git.Repository
 	h    plumbing.Hash
+
+	hiddenRefs []string
 }
 
 // Open opens a git repository at path. If ref is empty, HEAD is used.
@@ -212,6 +213,28 @@ func (g *Repo) LastCommit() (*Commit, error) {
 	return newCommit(c), nil
 }
 
+// FirstCommitTime returns commit time of the oldest root commit. It walks
+// the whole history, so callers should cache it.
+func (g *Repo) FirstCommitTime() (time.Time, error) {
+	if g.IsEmpty() {
+		return time.Time{}, nil
+	}
+
+	out, err := g.runGitCmd("log", "--max-parents=0", "--format=%ct", g.h.String())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("first commit: %w", err)
+	}
+
+	var first time.Time
+	for line := range strings.Lines(string(out)) {
+		t := parseUnix(strings.TrimSpace(line), time.Time{})
+		if !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first, nil
+}
+
 func (g *Repo) LastFileCommit(ctx context.Context, fpath string) (*Commit, error) {
 	path := path.Clean(fpath)
 	hash, err := g.lastFileCommitHash(ctx, path)
@@ -259,58 +282,6 @@ func (g *Repo) IsGoMod() bool {
 	return err == nil
 }
 
-func (g *Repo) Fetch(ctx context.Context) (isUpdated bool, err error) {
-	return g.fetch(ctx, nil)
-}
-
-func (g *Repo) FetchFromGithubWithToken(ctx context.Context, token string) (isUpdated bool, err error) {
-	return g.fetch(ctx, &http.BasicAuth{
-		Username: "x-access-token", // this can be anything but empty
-		Password: token,
-	})
-}
-
-func (g *Repo) fetch(ctx context.Context, auth transport.AuthMethod) (bool, error) {
-	rmt, err := g.r.Remote(originRemote)
-	if err != nil {
-		return false, fmt.Errorf("failed to get remote: %w", err)
-	}
-
-	err = rmt.FetchContext(ctx, &git.FetchOptions{
-		Auth:  auth,
-		Tags:  git.AllTags,
-		Prune: true,
-		Force: true,
-	})
-
-	isUpdated := !errors.Is(err, git.NoErrAlreadyUpToDate)
-	if err != nil && isUpdated {
-		return false, fmt.Errorf("failed to fetch: %w", err)
-	}
-
-	if !g.IsEmpty() {
-		return isUpdated, nil
-	}
-
-	refs, err := rmt.List(&git.ListOptions{Auth: auth})
-	if err != nil {
-		return false, fmt.Errorf("failed to list references: %w", err)
-	}
-
-	for _, ref := range refs {
-		if ref.Name() == plumbing.HEAD {
-			if err := g.r.Storer.SetReference(
-				plumbing.NewSymbolicReference(plumbing.HEAD, ref.Target()),
-			); err != nil {
-				return false, fmt.Errorf("failed to set HEAD: %w", err)
-			}
-			break
-		}
-	}
-
-	return isUpdated, nil
-}
-
 func (g *Repo) peelToCommit(h plumbing.Hash) plumbing.Hash {
 	obj, err := g.r.Object(plumbing.AnyObject, h)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing"
 
@@ -184,6 +185,25 @@ func TestRepo_LastCommit(t *testing.T) {
 	})
 }
 
+func TestRepo_FirstCommitTime(t *testing.T) {
+	t.Run("returns time of root commit", func(t *testing.T) {
+		r := newTestRepo(t)
+		first := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		r.commitFileAt("readme", "test", "init", first)
+		r.commitFile("latest.txt", "latest", "latest commit")
+
+		got, err := r.open().FirstCommitTime()
+		is.Err(t, err, nil)
+		is.Equal(t, got.Equal(first), true)
+	})
+
+	t.Run("empty repo", func(t *testing.T) {
+		got, err := newTestRepo(t).open().FirstCommitTime()
+		is.Err(t, err, nil)
+		is.Equal(t, got.IsZero(), true)
+	})
+}
+
 func TestRepo_LastFileCommit(t *testing.T) {
 	t.Run("returns last commit for root file", func(t *testing.T) {
 		r := newTestRepo(t)
@@ -39,6 +39,37 @@ func (t *TagReference) When() time.Time {
 	return t.when
 }
 
+// IsAnnotated reports whether the tag has its own object, with tagger and
+// message.
+func (t *TagReference) IsAnnotated() bool {
+	return t.tag != nil
+}
+
+// TaggerName returns name of the tagger, or "" for lightweight tags.
+func (t *TagReference) TaggerName() string {
+	if t.tag != nil {
+		return t.tag.Tagger.Name
+	}
+	return ""
+}
+
+// TaggerEmail returns email of the tagger, or "" for lightweight tags.
+func (t *TagReference) TaggerEmail() string {
+	if t.tag != nil {
+		return t.tag.Tagger.Email
+	}
+	return ""
+}
+
+// Tag returns tag by its short name, e.g. v1.0.0.
+func (g *Repo) Tag(name string) (*TagReference, error) {
+	ref, err := g.r.Reference(plumbing.NewTagReferenceName(name), true)
+	if err != nil {
+		return nil, fmt.Errorf("tag %q: %w", name, err)
+	}
+	return g.newTagReference(ref)
+}
+
 func (g *Repo) Tags() ([]*TagReference, error) {
 	iter, err := g.r.Tags()
 	if err != nil {
@@ -47,31 +78,12 @@ func (g *Repo) Tags() ([]*TagReference, error) {
 
 	tags := make([]*TagReference, 0)
 	if err := iter.ForEach(func(ref *plumbing.Reference) error {
-		obj, err := g.r.TagObject(ref.Hash())
-		switch err {
-		case nil:
-			tags = append(tags, &TagReference{
-				ref:  ref,
-				tag:  obj,
-				when: obj.Tagger.When,
-			})
-			return nil
-
-		case plumbing.ErrObjectNotFound:
-			commit, cerr := g.r.CommitObject(ref.Hash())
-			if cerr != nil {
-				return cerr
-			}
-
-			tags = append(tags, &TagReference{
-				ref:  ref,
-				when: commit.Committer.When,
-			})
-			return nil
-
-		default:
+		tag, err := g.newTagReference(ref)
+		if err != nil {
 			return err
 		}
+		tags = append(tags, tag)
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -82,6 +94,32 @@ func (g *Repo) Tags() ([]*TagReference, error) {
 	return tags, nil
 }
 
+func (g *Repo) newTagReference(ref *plumbing.Reference) (*TagReference, error) {
+	obj, err := g.r.TagObject(ref.Hash())
+	switch err {
+	case nil:
+		return &TagReference{
+			ref:  ref,
+			tag:  obj,
+			when: obj.Tagger.When,
+		}, nil
+
+	case plumbing.ErrObjectNotFound:
+		commit, cerr := g.r.CommitObject(ref.Hash())
+		if cerr != nil {
+			return nil, cerr
+		}
+
+		return &TagReference{
+			ref:  ref,
+			when: commit.Committer.When,
+		}, nil
+
+	default:
+		return nil, err
+	}
+}
+
 func (t *TagList) Len() int {
 	return len(t.refs)
 }
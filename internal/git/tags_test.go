@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-git/go-git/v5/plumbing"
+
 	"olexsmir.xyz/x/is"
 )
 
@@ -62,3 +64,35 @@ func TestRepo_Tags(t *testing.T) {
 		is.Equal(t, tags[2].Name(), "v1.0.0")
 	})
 }
+
+func TestRepo_Tag(t *testing.T) {
+	r := newTestRepo(t)
+	hash := r.commitFile("file.txt", "content", "A commit")
+	r.createTag("v1.0.0", hash)
+	tagTime := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
+	r.createAnnotatedTag("v2.0.0", "Release 2.0.0\n\n- new things\n", hash, tagTime)
+	repo := r.open()
+
+	t.Run("annotated", func(t *testing.T) {
+		tag, err := repo.Tag("v2.0.0")
+		is.Err(t, err, nil)
+		is.Equal(t, tag.Name(), "v2.0.0")
+		is.Equal(t, tag.IsAnnotated(), true)
+		is.Equal(t, tag.Message(), "Release 2.0.0\n\n- new things\n")
+		is.Equal(t, tag.TaggerName(), "Test User")
+		is.Equal(t, tag.TaggerEmail(), "test@test.local")
+		is.Equal(t, tag.When(), tagTime)
+	})
+
+	t.Run("lightweight", func(t *testing.T) {
+		tag, err := repo.Tag("v1.0.0")
+		is.Err(t, err, nil)
+		is.Equal(t, tag.IsAnnotated(), false)
+		is.Equal(t, tag.TaggerName(), "")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := repo.Tag("v3.0.0")
+		is.Err(t, err, plumbing.ErrReferenceNotFound)
+	})
+}
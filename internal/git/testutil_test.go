@@ -86,6 +86,46 @@ func (t *testRepo) deleteFile(name, msg string) plumbing.Hash {
 	return hash
 }
 
+// moveFile renames the file, and commits it with the new content.
+func (t *testRepo) moveFile(from, to, content, msg string) plumbing.Hash {
+	t.tb.Helper()
+
+	wt, err := t.r.Worktree()
+	is.Err(t.tb, err, nil)
+
+	_, err = wt.Remove(from)
+	is.Err(t.tb, err, nil)
+	return t.commitFile(to, content, msg)
+}
+
+// commitMerge commits files, with HEAD and other as parents.
+func (t *testRepo) commitMerge(msg string, other plumbing.Hash, files map[string]string) plumbing.Hash {
+	t.tb.Helper()
+
+	wt, err := t.r.Worktree()
+	is.Err(t.tb, err, nil)
+
+	for name, content := range files {
+		is.Err(t.tb, os.WriteFile(filepath.Join(t.path, name), []byte(content), 0o644), nil)
+		_, err = wt.Add(name)
+		is.Err(t.tb, err, nil)
+	}
+
+	head, err := t.r.Head()
+	is.Err(t.tb, err, nil)
+
+	hash, err := wt.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@test.local",
+			When:  time.Now(),
+		},
+		Parents: []plumbing.Hash{head.Hash(), other},
+	})
+	is.Err(t.tb, err, nil)
+	return hash
+}
+
 func (t *testRepo) createBranch(name string, hash plumbing.Hash) {
 	t.tb.Helper()
 	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash)
@@ -124,6 +164,24 @@ func (t *testRepo) createAnnotatedTag(name, msg string, hash plumbing.Hash, when
 	is.Err(t.tb, err, nil)
 }
 
+// hostileGitConfig sets global git config of the test to one changing output
+// of porcelain commands, which mugit must not depend on.
+func hostileGitConfig(tb testing.TB) {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "gitconfig")
+	is.Err(tb, os.WriteFile(path, []byte(`[diff]
+	noprefix = true
+	mnemonicPrefix = true
+	relative = true
+[format]
+	signature = ACME-CORP
+	coverLetter = true
+[log]
+	showSignature = true
+`), 0o644), nil)
+	tb.Setenv("GIT_CONFIG_GLOBAL", path)
+}
+
 func (t *testRepo) open(ref ...string) *Repo {
 	t.tb.Helper()
 	re := ""
@@ -134,3 +192,21 @@ func (t *testRepo) open(ref ...string) *Repo {
 	is.Err(t.tb, err, nil)
 	return r
 }
+
+// newMirror creates a bare mirror of the upstream repo, without syncing it.
+func newMirror(tb testing.TB, upstream *testRepo) *Repo {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "mirror.git")
+	is.Err(tb, Init(path), nil)
+
+	r, err := Open(path, "")
+	is.Err(tb, err, nil)
+	is.Err(tb, r.SetMirrorRemote(upstream.path), nil)
+	return r
+}
+
+func (t *testRepo) hasRef(name string) bool {
+	t.tb.Helper()
+	_, err := t.r.Reference(plumbing.ReferenceName(name), false)
+	return err == nil
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
@@ -267,3 +268,56 @@ func ancestors(p string) []string {
 	}
 	return ancestors
 }
+
+// Hash returns hash of the commit repo is opened at.
+func (g *Repo) Hash() string {
+	return g.h.String()
+}
+
+type TreeFile struct {
+	Path string
+	Hash string // hash of the blob
+	Size int64
+}
+
+// Files lists all regular files in the tree, including nested ones.
+func (g *Repo) Files() ([]TreeFile, error) {
+	c, err := g.r.CommitObject(g.h)
+	if err != nil {
+		return nil, fmt.Errorf("commit object: %w", err)
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("file tree: %w", err)
+	}
+
+	var files []TreeFile
+	err = tree.Files().ForEach(func(f *object.File) error {
+		// symlinks aren't followed, their content is just a path
+		if f.Mode == filemode.Regular || f.Mode == filemode.Executable {
+			files = append(files, TreeFile{Path: f.Name, Hash: f.Hash.String(), Size: f.Size})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+	return files, nil
+}
+
+// Blob returns contents of the blob by its hash.
+func (g *Repo) Blob(hash string) ([]byte, error) {
+	blob, err := g.r.BlobObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, fmt.Errorf("blob object %s: %w", hash, err)
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("blob reader: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	return io.ReadAll(reader)
+}
@@ -154,3 +154,21 @@ func TestFileContent_String(t *testing.T) {
 		}).String(), "")
 	})
 }
+
+func TestRepo_Files(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("main.go", "package main\n", "Add main.go")
+	r.commitFile("dir/sub/a.txt", "a\n", "Add a.txt")
+	repo := r.open()
+
+	files, err := repo.Files()
+	is.Err(t, err, nil)
+	is.Equal(t, len(files), 2)
+	is.Equal(t, files[0].Path, "dir/sub/a.txt")
+	is.Equal(t, files[0].Size, int64(2))
+	is.Equal(t, files[1].Path, "main.go")
+
+	content, err := repo.Blob(files[1].Hash)
+	is.Err(t, err, nil)
+	is.Equal(t, string(content), "package main\n")
+}
@@ -0,0 +1,161 @@
+package git
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+const (
+	// wordDiffMaxRun is max number of lines in deleted or added run, which
+	// lines are paired for word diff. Bigger changes are rewrites anyway.
+	wordDiffMaxRun = 64
+
+	// wordDiffMaxLineLen is max length of paired line, longer lines aren't
+	// compared.
+	wordDiffMaxLineLen = 512
+)
+
+// Span is a range of bytes in a line.
+type Span struct {
+	Start, End int
+}
+
+// lineChanges pairs runs of deleted lines with following runs of added lines,
+// and finds changed words of each pair. Result is indexed like lines, it's
+// nil for lines that weren't paired, or have nothing in common.
+func lineChanges(lines []gitdiff.Line) [][]Span {
+	var out [][]Span
+	set := func(i int, spans []Span) {
+		if out == nil {
+			out = make([][]Span, len(lines))
+		}
+		out[i] = spans
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op != gitdiff.OpDelete {
+			i++
+			continue
+		}
+
+		dels := i
+		for i < len(lines) && lines[i].Op == gitdiff.OpDelete {
+			i++
+		}
+		adds := i
+		for i < len(lines) && lines[i].Op == gitdiff.OpAdd {
+			i++
+		}
+
+		nDels, nAdds := adds-dels, i-adds
+		if nAdds == 0 || nDels > wordDiffMaxRun || nAdds > wordDiffMaxRun {
+			continue
+		}
+		for j := range min(nDels, nAdds) {
+			oldSpans, newSpans, ok := wordDiff(
+				strings.TrimSuffix(lines[dels+j].Line, "\n"),
+				strings.TrimSuffix(lines[adds+j].Line, "\n"),
+			)
+			if ok {
+				set(dels+j, oldSpans)
+				set(adds+j, newSpans)
+			}
+		}
+	}
+	return out
+}
+
+// wordDiff finds spans of words, that differ between the lines, using longest
+// common subsequence of their words. Reports false, if lines are too long, or
+// have nothing but whitespace in common.
+func wordDiff(a, b string) ([]Span, []Span, bool) {
+	if a == b || len(a) > wordDiffMaxLineLen || len(b) > wordDiffMaxLineLen {
+		return nil, nil, false
+	}
+
+	at, bt := splitWords(a), splitWords(b)
+
+	// lcs[i][j] is length of common subsequence of at[i:] and bt[j:]
+	n, m := len(at), len(bt)
+	lcs := make([]int32, (n+1)*(m+1))
+	idx := func(i, j int) int { return i*(m+1) + j }
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if at[i].text == bt[j].text {
+				lcs[idx(i, j)] = lcs[idx(i+1, j+1)] + 1
+			} else {
+				lcs[idx(i, j)] = max(lcs[idx(i+1, j)], lcs[idx(i, j+1)])
+			}
+		}
+	}
+
+	var aSpans, bSpans []Span
+	var common bool
+	for i, j := 0, 0; i < n || j < m; {
+		switch {
+		case i < n && j < m && at[i].text == bt[j].text:
+			common = common || strings.TrimSpace(at[i].text) != ""
+			i++
+			j++
+		case j == m || (i < n && lcs[idx(i+1, j)] >= lcs[idx(i, j+1)]):
+			aSpans = addSpan(aSpans, at[i].Span)
+			i++
+		default:
+			bSpans = addSpan(bSpans, bt[j].Span)
+			j++
+		}
+	}
+	if !common {
+		return nil, nil, false
+	}
+	return aSpans, bSpans, true
+}
+
+type word struct {
+	Span
+	text string
+}
+
+// splitWords splits line into runs of letters and digits, runs of spaces,
+// and single other characters.
+func splitWords(s string) []word {
+	var out []word
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		if class := runeClass(r); class != 0 {
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if runeClass(r) != class {
+					break
+				}
+				end += size
+			}
+		}
+		out = append(out, word{Span{i, end}, s[i:end]})
+		i = end
+	}
+	return out
+}
+
+func runeClass(r rune) int {
+	switch {
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	case unicode.IsSpace(r):
+		return 2
+	}
+	return 0
+}
+
+// addSpan appends span, merging it with the last one if they touch.
+func addSpan(spans []Span, s Span) []Span {
+	if l := len(spans); l > 0 && spans[l-1].End == s.Start {
+		spans[l-1].End = s.End
+		return spans
+	}
+	return append(spans, s)
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/xml"
+	"errors"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
+
+	"olexsmir.xyz/mugit/internal/git"
 )
 
 type rssFeedXML struct {
@@ -28,7 +32,12 @@ type rssItemXML struct {
 	PubDate     string `xml:"pubDate,omitempty"`
 }
 
+// repoFeedHandler serves branches and tags of the repo. If the log filter is
+// set, it serves commits of the default branch that match it instead.
 func (h *handlers) repoFeedHandler(w http.ResponseWriter, r *http.Request) {
+	filter, query := parseLogFilter(r)
+	query.After = ""
+
 	repo, err := h.openPublicRepo(r.PathValue("name"), "")
 	if err != nil {
 		h.write404(w, r.URL.Path, err)
@@ -57,6 +66,17 @@ func (h *handlers) repoFeedHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	if filter.IsSet() {
+		commits, err := repo.Log(r.Context(), query)
+		if err != nil {
+			h.write500(w, err)
+			return
+		}
+		feed.Channel.Items = h.commitItems(repoName, commits)
+		h.writeFeed(w, feed)
+		return
+	}
+
 	// branches
 	branches, err := repo.Branches()
 	if err != nil {
@@ -95,12 +115,7 @@ func (h *handlers) repoFeedHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/rss+xml")
-	_, _ = w.Write([]byte(xml.Header))
-	if err := xml.NewEncoder(w).Encode(feed); err != nil {
-		h.write500(w, err)
-		return
-	}
+	h.writeFeed(w, feed)
 }
 
 func (h *handlers) indexFeedHandler(w http.ResponseWriter, r *http.Request) {
@@ -139,10 +154,78 @@ func (h *handlers) indexFeedHandler(w http.ResponseWriter, r *http.Request) {
 		feed.Channel.Items = append(feed.Channel.Items, it)
 	}
 
+	h.writeFeed(w, feed)
+}
+
+// logFeedHandler serves commits of the log, with the same filters as the log page.
+func (h *handlers) logFeedHandler(w http.ResponseWriter, r *http.Request) {
+	ref := h.parseRef(r.PathValue("ref"))
+	treePath := strings.Trim(r.PathValue("rest"), "/")
+	_, query := parseLogFilter(r)
+	query.Path = treePath
+	query.After = ""
+
+	repo, err := h.openPublicRepo(r.PathValue("name"), ref)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	commits, err := repo.Log(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, git.ErrFileNotFound) {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
+		h.write500(w, err)
+		return
+	}
+
+	repoName := repo.Name()
+	feedLink, err := url.JoinPath("http://", h.c.Meta.Host, repoName, "log", url.PathEscape(ref), treePath)
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	title := repoName + ": log"
+	if treePath != "" {
+		title += " of " + treePath
+	}
+
+	feed := rssFeedXML{
+		Version: "2.0",
+		Channel: rssChannelXML{
+			Title:       title,
+			Link:        feedLink,
+			Description: "commits on " + ref,
+		},
+	}
+
+	feed.Channel.Items = h.commitItems(repoName, commits)
+	h.writeFeed(w, feed)
+}
+
+func (h *handlers) commitItems(repoName string, commits []*git.Commit) []rssItemXML {
+	items := make([]rssItemXML, 0, len(commits))
+	for _, c := range commits {
+		summary, _, _ := strings.Cut(c.Message, "\n")
+		href, _ := url.JoinPath("http://", h.c.Meta.Host, repoName, "commit", c.Hash)
+		items = append(items, rssItemXML{
+			Title:       strings.TrimSpace(summary),
+			Link:        href,
+			GUID:        href,
+			Description: c.Message,
+			PubDate:     c.Committed.Format(time.RFC1123Z),
+		})
+	}
+	return items
+}
+
+func (h *handlers) writeFeed(w http.ResponseWriter, feed rssFeedXML) {
 	w.Header().Set("Content-Type", "application/rss+xml")
 	_, _ = w.Write([]byte(xml.Header))
 	if err := xml.NewEncoder(w).Encode(feed); err != nil {
 		h.write500(w, err)
-		return
 	}
 }
@@ -26,6 +26,7 @@ func (h *handlers) infoRefsHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Cache-Control", "no-cache, max-age=0, must-revalidate")
 
 		w.WriteHeader(http.StatusOK)
+		repo.HideRefs(h.c.Repo.HideRefs)
 		if err := repo.InfoRefs(r.Context(), gitProtocol, w); err != nil {
 			_ = git.PackError(w, err.Error())
 			slog.Error("git: info/refs", "err", err)
@@ -75,6 +76,7 @@ func (h *handlers) uploadPackHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache, max-age=0, must-revalidate")
 
 	w.WriteHeader(http.StatusOK)
+	repo.HideRefs(h.c.Repo.HideRefs)
 	if err := repo.UploadPack(r.Context(), true, gitProtocol, bodyReader, newFlushWriter(w)); err != nil {
 		_ = git.PackError(w, err.Error())
 		slog.Error("git: upload-pack", "err", err)
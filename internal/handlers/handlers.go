@@ -13,6 +13,8 @@ import (
 	"olexsmir.xyz/mugit/internal/config"
 	"olexsmir.xyz/mugit/internal/git"
 	"olexsmir.xyz/mugit/internal/humanize"
+	"olexsmir.xyz/mugit/internal/markup"
+	"olexsmir.xyz/mugit/internal/search"
 	"olexsmir.xyz/mugit/web"
 )
 
@@ -23,9 +25,14 @@ type handlers struct {
 	repoListCache cache.Cacher[[]repoList]
 	readmeCache   cache.Cacher[template.HTML]
 	diffCache     cache.Cacher[*git.NiceDiff]
+	createdCache  cache.Cacher[time.Time] // first commit of repo rarely changes
+
+	markup *markup.Registry
+
+	search *search.Index // nil if search is disabled
 }
 
-func InitRoutes(cfg *config.Config) http.Handler {
+func InitRoutes(cfg *config.Config, index *search.Index) http.Handler {
 	tmpls := template.Must(template.New("").
 		Funcs(templateFuncs).
 		ParseFS(web.TemplatesFS, "*"))
@@ -34,27 +41,40 @@ func InitRoutes(cfg *config.Config) http.Handler {
 		cache.NewInMemory[[]repoList](cfg.Cache.HomePage),
 		cache.NewInMemory[template.HTML](cfg.Cache.Readme),
 		cache.NewInMemory[*git.NiceDiff](cfg.Cache.Diff),
+		cache.NewInMemory[time.Time](24 * time.Hour),
+		markup.NewRegistry(cfg.Markup),
+		index,
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /", h.indexHandler)
 	mux.HandleFunc("GET /index.xml", h.indexFeedHandler)
+	mux.HandleFunc("GET /search", h.globalSearchHandler)
 	mux.HandleFunc("GET /static/{file}", h.serveStaticHandler)
 	mux.HandleFunc("GET /{name}/{$}", h.repoIndexHandler)
 	mux.HandleFunc("GET /{name}/info/refs", h.infoRefsHandler)
 	mux.HandleFunc("POST /{name}/git-upload-pack", h.uploadPackHandler)
 	mux.HandleFunc("POST /{name}/git-receive-pack", h.receivePackHandler)
 	mux.HandleFunc("GET /{name}/feed/{$}", h.repoFeedHandler)
+	mux.HandleFunc("GET /{name}/feed/log/{ref}", h.logFeedHandler)
+	mux.HandleFunc("GET /{name}/feed/log/{ref}/{rest...}", h.logFeedHandler)
 	mux.HandleFunc("GET /{name}/tree/{ref}/{rest...}", h.repoTreeHandler)
 	mux.HandleFunc("GET /{name}/blob/{ref}/{rest...}", h.fileContentsHandler)
 	mux.HandleFunc("GET /{name}/raw/{ref}/{rest...}", h.rawFileContentsHandler)
+	mux.HandleFunc("GET /{name}/blame/{ref}/{rest...}", h.blameHandler)
+	mux.HandleFunc("GET /{name}/search/{ref}", h.searchHandler)
 	mux.HandleFunc("GET /{name}/log/{ref}", h.logHandler)
+	mux.HandleFunc("GET /{name}/log/{ref}/{rest...}", h.logHandler)
 	mux.HandleFunc("GET /{name}/commit/{ref}", h.commitHandler)
-	mux.HandleFunc("GET /{name}/compare/{ref1}/{ref2}", h.compareHandler)
+	mux.HandleFunc("GET /{name}/compare/{range...}", h.compareHandler)
 	mux.HandleFunc("GET /{name}/refs/{$}", h.refsHandler)
 	mux.HandleFunc("GET /{name}/archive/{ref}", h.archiveHandler)
+	mux.HandleFunc("GET /{name}/tag/{tag}", h.tagHandler)
+	mux.HandleFunc("GET /{name}/releases/{$}", h.releasesHandler)
+	mux.HandleFunc("GET /{name}/releases/{tag}/{file}", h.releaseAssetHandler)
 
 	handler := h.recoverMiddleware(mux)
+	handler = h.headersMiddleware(handler)
 	return h.loggingMiddleware(handler)
 }
 
@@ -78,6 +98,7 @@ var templateFuncs = template.FuncMap{
 	"inc64":           func(n int64) int64 { return n + 1 },
 	"humanizeTime":    func(t time.Time) string { return t.Format("2006-01-02 15:04:05 MST") },
 	"humanizeRelTime": humanize.Time,
+	"humanizeBytes":   humanize.Bytes,
 	"urlencode":       url.PathEscape,
 	"commitSummary":   commitSummary,
 	"dict":            dict,
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/git"
 	"olexsmir.xyz/x/is"
 )
 
@@ -114,3 +121,151 @@ func TestTemplate_CommitSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestMarkMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		isRegexp  bool
+		matchCase bool
+		line      string
+		want      template.HTML
+	}{
+		{
+			name:  "literal ignores case",
+			query: "a.b", line: "A.B and axb",
+			want: "<mark>A.B</mark> and axb",
+		},
+		{
+			name:  "match case",
+			query: "Foo", matchCase: true, line: "foo Foo",
+			want: "foo <mark>Foo</mark>",
+		},
+		{
+			name:  "regexp",
+			query: `<\w+>`, isRegexp: true, line: "<a> & <b>",
+			want: "<mark>&lt;a&gt;</mark> &amp; <mark>&lt;b&gt;</mark>",
+		},
+		{
+			name:  "regexp not valid in go is only escaped",
+			query: `[[:alpha:]`, isRegexp: true, line: "<a>",
+			want: "&lt;a&gt;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := searchMarkRegexp(tt.query, tt.isRegexp, tt.matchCase)
+			is.Equal(t, markMatches(re, tt.line), tt.want)
+		})
+	}
+}
+
+func TestSortRepos(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	repos := []repoList{
+		{Name: "b", LastCommit: day(3), Created: day(1)},
+		{Name: "tool-a", Section: "tools", LastCommit: day(1), Created: day(2)},
+		{Name: "a", LastCommit: day(2), Created: day(3)},
+		{Name: "tool-b", Section: "tools", LastCommit: day(2), Created: day(1)},
+	}
+	names := func(sections []IndexSection) (out []string) {
+		for _, s := range sections {
+			out = append(out, "["+s.Name+"]")
+			for _, r := range s.Repos {
+				out = append(out, r.Name)
+			}
+		}
+		return out
+	}
+
+	sortRepos(repos, "")
+	is.Equal(t, names(groupBySection(repos)), []string{"[]", "b", "a", "[tools]", "tool-b", "tool-a"})
+
+	sortRepos(repos, "name")
+	is.Equal(t, names(groupBySection(repos)), []string{"[]", "a", "b", "[tools]", "tool-a", "tool-b"})
+
+	sortRepos(repos, "created")
+	is.Equal(t, names(groupBySection(repos)), []string{"[]", "a", "b", "[tools]", "tool-a", "tool-b"})
+}
+
+func TestHeadersMiddleware(t *testing.T) {
+	h := &handlers{c: &config.Config{Server: config.ServerConfig{Headers: config.HeadersConfig{
+		CSP:          "default-src 'none'",
+		FrameOptions: "DENY",
+	}}}}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/raw" {
+			w.Header().Set("Content-Security-Policy", "sandbox")
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	h.headersMiddleware(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	is.Equal(t, rec.Header().Get("Content-Security-Policy"), "default-src 'none'")
+	is.Equal(t, rec.Header().Get("X-Frame-Options"), "DENY")
+	is.Equal(t, rec.Header().Get("X-Content-Type-Options"), "nosniff")
+	_, hsts := rec.Header()["Strict-Transport-Security"]
+	is.Equal(t, hsts, false)
+
+	rec = httptest.NewRecorder()
+	h.headersMiddleware(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/raw", nil))
+	is.Equal(t, rec.Header().Get("Content-Security-Policy"), "sandbox")
+}
+
+func TestDiffParent(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+		err   error
+	}{
+		{"", git.DiffCombined, nil},
+		{"parent=2", 2, nil},
+		{"diff=remerge", git.DiffRemerge, nil},
+		{"diff=remerge&parent=2", git.DiffRemerge, nil},
+		{"parent=0", 0, git.ErrNoParent},
+		{"parent=first", 0, git.ErrNoParent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			params, _ := url.ParseQuery(tt.query)
+			got, err := diffParent(params)
+			is.Err(t, err, tt.err)
+			is.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestMergeDiffs(t *testing.T) {
+	params, _ := url.ParseQuery("view=split&parent=2")
+	links := mergeDiffs(params, []string{"aaaaaaa", "bbbbbbb"}, 2)
+	is.Equal(t, links, []DiffLink{
+		{Name: "combined", URL: "?view=split"},
+		{Name: "parent aaaaaaa", URL: "?parent=1&view=split"},
+		{Name: "parent bbbbbbb", URL: "?parent=2&view=split", Active: true},
+		{Name: "merge changes", URL: "?diff=remerge&view=split"},
+	})
+}
+
+func TestCutPatchFormat(t *testing.T) {
+	tests := []struct {
+		ref, wantRef, wantFormat string
+	}{
+		{"abc1234.patch", "abc1234", "patch"},
+		{"master.diff", "master", "diff"},
+		{"master", "master", ""},
+		{"v1.0", "v1.0", ""},
+		{".patch", ".patch", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, format := cutPatchFormat(tt.ref)
+			is.Equal(t, ref, tt.wantRef)
+			is.Equal(t, format, tt.wantFormat)
+		})
+	}
+}
+
+func TestCompareURL(t *testing.T) {
+	is.Equal(t, compareURL("repo", git.Range{Base: "main", Head: "feature/x"}), "/repo/compare/main...feature%2Fx")
+	is.Equal(t, compareURL("repo", git.Range{Base: "v1.0", Head: "v2.0", TwoDot: true}), "/repo/compare/v1.0..v2.0")
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+
+	"olexsmir.xyz/mugit/internal/git"
+	"olexsmir.xyz/mugit/internal/markup"
+	"olexsmir.xyz/mugit/internal/release"
+)
+
+type RepoTag struct {
+	Desc   string
+	Ref    string // the tag
+	Tag    *git.TagReference
+	Notes  template.HTML // rendered message of annotated tag
+	Commit *git.Commit
+	Assets []release.Asset
+}
+
+func (h *handlers) tagHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	tagName := h.parseRef(r.PathValue("tag"))
+
+	repo, err := h.openPublicRepo(name, "refs/tags/"+tagName)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	tag, err := repo.Tag(tagName)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	desc, err := repo.Description()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	commit, err := repo.LastCommit()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	assets, err := h.releaseAssets(name, tagName)
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	var notes template.HTML
+	if msg := tag.Message(); msg != "" {
+		cacheKey := fmt.Sprintf("tag:%s:%s:%s", repo.Name(), tagName, repo.Hash())
+		if v, found := h.readmeCache.Get(cacheKey); found {
+			notes = v
+		} else {
+			out, rerr := h.markup.RenderMarkdown(r.Context(), markup.Context{RepoName: repo.Name(), Ref: tagName}, []byte(msg))
+			if rerr != nil {
+				h.write500(w, rerr)
+				return
+			}
+			notes = template.HTML(out)
+			h.readmeCache.Set(cacheKey, notes)
+		}
+	}
+
+	h.templ(w, "repo_tag", h.pageData(repo, RepoTag{
+		Desc:   desc,
+		Ref:    tagName,
+		Tag:    tag,
+		Notes:  notes,
+		Commit: commit,
+		Assets: assets,
+	}))
+}
+
+type RepoReleases struct {
+	Desc     string
+	Ref      string
+	Releases []Release
+}
+
+type Release struct {
+	Tag    *git.TagReference
+	Assets []release.Asset
+}
+
+func (h *handlers) releasesHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	repo, err := h.openPublicRepo(name, "")
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	desc, err := repo.Description()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	master, err := repo.DefaultBranch()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	releases := make([]Release, 0, len(tags))
+	for _, tag := range tags {
+		assets, err := h.releaseAssets(name, tag.Name())
+		if err != nil {
+			h.write500(w, err)
+			return
+		}
+		releases = append(releases, Release{Tag: tag, Assets: assets})
+	}
+
+	h.templ(w, "repo_releases", h.pageData(repo, RepoReleases{
+		Desc:     desc,
+		Ref:      master,
+		Releases: releases,
+	}))
+}
+
+func (h *handlers) releaseAssetHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	tag := h.parseRef(r.PathValue("tag"))
+	file := r.PathValue("file")
+
+	repo, err := h.openPublicRepo(name, "")
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	// assets of deleted tags are left on disk, don't serve them
+	if _, err = repo.Tag(tag); err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	store, err := h.releaseStore(name)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	f, err := store.Open(tag, file)
+	if err != nil {
+		if errors.Is(err, release.ErrNotFound) || errors.Is(err, release.ErrInvalidName) || errors.Is(err, release.ErrInvalidTag) {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
+		h.write500(w, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file}))
+	// assets could be html or svg, same as raw files
+	w.Header().Set("Content-Security-Policy", cmp.Or(h.c.Server.Headers.RawCSP, "sandbox"))
+	http.ServeContent(w, r, file, info.ModTime(), f)
+}
+
+func (h *handlers) releaseStore(name string) (*release.Store, error) {
+	path, err := git.ResolvePath(h.c.Repo.Dir, git.ResolveName(name))
+	if err != nil {
+		return nil, err
+	}
+	return release.NewStore(path), nil
+}
+
+func (h *handlers) releaseAssets(name, tag string) ([]release.Asset, error) {
+	store, err := h.releaseStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Assets(tag)
+}
@@ -1,29 +1,41 @@
 package handlers
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"html"
 	"html/template"
+	"io"
 	"log/slog"
+	"maps"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"olexsmir.xyz/mugit/internal/git"
-	"olexsmir.xyz/mugit/internal/markdown"
+	"olexsmir.xyz/mugit/internal/highlight"
+	"olexsmir.xyz/mugit/internal/markup"
 )
 
 type Meta struct {
-	Title       string
-	Description string
-	Host        string
-	IsEmpty     bool
-	GoMod       bool
-	SSHEnabled  bool
+	Title         string
+	Description   string
+	Host          string
+	IsEmpty       bool
+	GoMod         bool
+	SSHEnabled    bool
+	SearchEnabled bool
 }
 
 type RepoBase struct {
@@ -37,13 +49,122 @@ type PageData[T any] struct {
 	P        T
 }
 
+// indexPageSize is number of repos per page of the index.
+const indexPageSize = 50
+
+type Index struct {
+	Query    string
+	Sort     string
+	Sections []IndexSection
+	Total    int
+	Page     int
+	Pages    int
+	PrevURL  string
+	NextURL  string
+}
+
+type IndexSection struct {
+	Name  string // empty for repos without section
+	Repos []repoList
+}
+
 func (h *handlers) indexHandler(w http.ResponseWriter, r *http.Request) {
 	repos, err := h.listPublicRepos()
 	if err != nil {
 		h.write500(w, err)
 		return
 	}
-	h.templ(w, "index", h.pageData(nil, repos))
+
+	params := r.URL.Query()
+	p := Index{
+		Query: strings.TrimSpace(params.Get("q")),
+		Sort:  params.Get("sort"),
+		Page:  1,
+	}
+	if n, err := strconv.Atoi(params.Get("page")); err == nil && n > 1 {
+		p.Page = n
+	}
+
+	// cached list is shared, it must not be modified
+	repos = slices.Clone(repos)
+	if p.Query != "" {
+		q := strings.ToLower(p.Query)
+		repos = slices.DeleteFunc(repos, func(r repoList) bool {
+			return !strings.Contains(strings.ToLower(r.Name), q) &&
+				!strings.Contains(strings.ToLower(r.Desc), q)
+		})
+	}
+	if p.Sort == "created" {
+		h.setCreated(repos)
+	}
+	sortRepos(repos, p.Sort)
+
+	p.Total = len(repos)
+	p.Pages = max(1, (len(repos)+indexPageSize-1)/indexPageSize)
+	p.Page = min(p.Page, p.Pages)
+	start := (p.Page - 1) * indexPageSize
+	end := min(start+indexPageSize, len(repos))
+	p.Sections = groupBySection(repos[start:end])
+
+	if p.Page > 1 {
+		p.PrevURL = withParam(params, "page", strconv.Itoa(p.Page-1))
+	}
+	if p.Page < p.Pages {
+		p.NextURL = withParam(params, "page", strconv.Itoa(p.Page+1))
+	}
+
+	h.templ(w, "index", h.pageData(nil, p))
+}
+
+// setCreated sets time of the first commit of repos. It's only needed for
+// sorting by it, and takes walking full history of each repo, unless cached.
+func (h *handlers) setCreated(repos []repoList) {
+	for i, r := range repos {
+		created, found := h.createdCache.Get(r.Name)
+		if !found {
+			repo, err := h.openPublicRepo(r.Name, "")
+			if err == nil {
+				created, err = repo.FirstCommitTime()
+			}
+			if err != nil {
+				slog.Error("index: first commit time", "repo", r.Name, "err", err)
+				continue
+			}
+			if !created.IsZero() {
+				h.createdCache.Set(r.Name, created)
+			}
+		}
+		repos[i].Created = created
+	}
+}
+
+// sortRepos sorts repos by section, and then by given order.
+func sortRepos(repos []repoList, order string) {
+	slices.SortStableFunc(repos, func(a, b repoList) int {
+		if c := cmp.Compare(a.Section, b.Section); c != 0 {
+			return c
+		}
+		switch order {
+		case "name":
+			return cmp.Compare(a.Name, b.Name)
+		case "created":
+			return b.Created.Compare(a.Created)
+		default:
+			return b.LastCommit.Compare(a.LastCommit)
+		}
+	})
+}
+
+// groupBySection groups repos sorted by [sortRepos].
+func groupBySection(repos []repoList) []IndexSection {
+	var out []IndexSection
+	for _, r := range repos {
+		if len(out) == 0 || out[len(out)-1].Name != r.Section {
+			out = append(out, IndexSection{Name: r.Section})
+		}
+		out[len(out)-1].Repos = append(out[len(out)-1].Repos, r)
+	}
+	return out
 }
 
 type RepoIndex struct {
@@ -96,7 +217,7 @@ func (h *handlers) repoIndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p.Readme, err = h.renderReadme(repo, p.Ref, "")
+	p.Readme, err = h.renderReadme(r.Context(), repo, p.Ref, "")
 	if err != nil {
 		h.write500(w, err)
 		return
@@ -142,13 +263,21 @@ func (h *handlers) repoTreeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// relative links in markup files lead to tree, files are shown by blob
+	if treePath != "" {
+		if isFile, _ := repo.IsFile(treePath); isFile {
+			http.Redirect(w, r, fmt.Sprintf("/%s/blob/%s/%s", repo.Name(), ref, treePath), http.StatusFound)
+			return
+		}
+	}
+
 	tree, err := repo.FileTree(r.Context(), treePath)
 	if err != nil {
 		h.write500(w, err)
 		return
 	}
 
-	readme, err := h.renderReadme(repo, ref, treePath)
+	readme, err := h.renderReadme(r.Context(), repo, ref, treePath)
 	if err != nil {
 		h.write500(w, err)
 		return
@@ -168,7 +297,7 @@ func (h *handlers) repoTreeHandler(w http.ResponseWriter, r *http.Request) {
 type RepoFile struct {
 	Ref         string
 	Desc        string
-	Lines       []string
+	Lines       []template.HTML
 	LastCommit  *git.Commit
 	Breadcrumbs []Breadcrumb
 	Path        string
@@ -176,6 +305,11 @@ type RepoFile struct {
 	IsBinary    bool
 	Mime        string
 	Size        int64
+
+	// Rendered is html of markup file, shown unless source is requested with ?plain=1.
+	Rendered template.HTML
+	IsMarkup bool
+	Plain    bool
 }
 
 func (h *handlers) fileContentsHandler(w http.ResponseWriter, r *http.Request) {
@@ -222,12 +356,104 @@ func (h *handlers) fileContentsHandler(w http.ResponseWriter, r *http.Request) {
 
 	p.Breadcrumbs = Breadcrumbs(treePath)
 	if !fc.IsImage && !fc.IsBinary {
-		p.Lines = strings.Split(strings.TrimRight(fc.String(), "\n"), "\n")
+		p.Plain = r.URL.Query().Get("plain") != ""
+		p.Rendered, p.IsMarkup = h.renderMarkup(r.Context(), repo.Name(), ref, treePath, fc.Content)
+		if p.IsMarkup && !p.Plain {
+			h.templ(w, "repo_file", h.pageData(repo, p))
+			return
+		}
+
+		content := strings.TrimRight(fc.String(), "\n")
+		lexer := highlight.Lexer(treePath, repo.Attributes().LinguistLanguage(treePath), content)
+		p.Lines = highlight.Lines(lexer, content)
 	}
 
 	h.templ(w, "repo_file", h.pageData(repo, p))
 }
 
+type RepoBlame struct {
+	Ref         string
+	Desc        string
+	Path        string
+	Breadcrumbs []Breadcrumb
+	Runs        []BlameRun
+}
+
+type BlameRun struct {
+	*git.BlameRun
+	Lines []BlameLine
+}
+
+type BlameLine struct {
+	Number int
+	HTML   template.HTML
+}
+
+func (h *handlers) blameHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	ref := h.parseRef(r.PathValue("ref"))
+	treePath := r.PathValue("rest")
+
+	repo, err := h.openPublicRepo(name, ref)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	fc, err := repo.FileContent(treePath)
+	if err != nil {
+		if errors.Is(err, git.ErrFileNotFound) {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
+		h.write500(w, err)
+		return
+	}
+
+	// there's nothing to blame line by line in binary files
+	if fc.IsBinary || fc.IsImage {
+		http.Redirect(w, r, fmt.Sprintf("/%s/blob/%s/%s", repo.Name(), ref, treePath), http.StatusFound)
+		return
+	}
+
+	runs, err := repo.Blame(r.Context(), treePath)
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	desc, err := repo.Description()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	content := strings.TrimRight(fc.String(), "\n")
+	lexer := highlight.Lexer(treePath, repo.Attributes().LinguistLanguage(treePath), content)
+	lines := highlight.Lines(lexer, content)
+
+	p := RepoBlame{
+		Ref:         ref,
+		Desc:        desc,
+		Path:        treePath,
+		Breadcrumbs: Breadcrumbs(treePath),
+		Runs:        make([]BlameRun, len(runs)),
+	}
+	for i := range runs {
+		run := BlameRun{BlameRun: &runs[i]}
+		for _, l := range runs[i].Lines {
+			bl := BlameLine{Number: l.Number}
+			if l.Number-1 < len(lines) {
+				bl.HTML = lines[l.Number-1]
+			}
+			run.Lines = append(run.Lines, bl)
+		}
+		p.Runs[i] = run
+	}
+
+	h.templ(w, "repo_blame", h.pageData(repo, p))
+}
+
 func (h *handlers) rawFileContentsHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
 	ref := h.parseRef(r.PathValue("ref"))
@@ -256,21 +482,115 @@ func (h *handlers) rawFileContentsHandler(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", fc.Mime)
 	w.Header().Set("Content-Disposition", "attachment")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// html and svg files must not run scripts on our origin, even if opened directly
+	w.Header().Set("Content-Security-Policy", cmp.Or(h.c.Server.Headers.RawCSP, "sandbox"))
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(fc.Content)
 }
 
 type RepoLog struct {
-	Desc      string
-	Commits   []*git.Commit
-	Ref       string
-	NextAfter string
+	Desc        string
+	Commits     []*git.Commit
+	Ref         string
+	NextURL     string // "load more" link, keeps the filter
+	FeedURL     string
+	Path        string // empty for log of the whole repo
+	Breadcrumbs []Breadcrumb
+	Filter      LogFilter
+}
+
+// LogFilter is the log filter as it was submitted in the query.
+type LogFilter struct {
+	Author      string
+	Committer   string
+	Since       string
+	Until       string
+	Grep        string
+	FirstParent bool
+	NoMerges    bool
+
+	query url.Values
+}
+
+func (f LogFilter) IsSet() bool { return len(f.query) > 0 }
+
+// withQuery appends the filter, and extra params to the link.
+func (f LogFilter) withQuery(link string, extra ...string) string {
+	v := maps.Clone(f.query)
+	for i := 0; i+1 < len(extra); i += 2 {
+		v.Set(extra[i], extra[i+1])
+	}
+	if len(v) == 0 {
+		return link
+	}
+	return link + "?" + v.Encode()
+}
+
+const logDateFormat = "2006-01-02"
+
+// parseLogFilter reads the log filter from query parameters. Malformed dates
+// are ignored.
+func parseLogFilter(r *http.Request) (LogFilter, git.LogQuery) {
+	params := r.URL.Query()
+	f := LogFilter{
+		Author:      strings.TrimSpace(params.Get("author")),
+		Committer:   strings.TrimSpace(params.Get("committer")),
+		Since:       params.Get("since"),
+		Until:       params.Get("until"),
+		Grep:        strings.TrimSpace(params.Get("grep")),
+		FirstParent: params.Get("first_parent") != "",
+		NoMerges:    params.Get("no_merges") != "",
+	}
+	q := git.LogQuery{
+		After:       params.Get("after"),
+		Author:      f.Author,
+		Committer:   f.Committer,
+		Grep:        f.Grep,
+		FirstParent: f.FirstParent,
+		NoMerges:    f.NoMerges,
+	}
+
+	if t, err := time.Parse(logDateFormat, f.Since); err == nil {
+		q.Since = t
+	} else {
+		f.Since = ""
+	}
+	if t, err := time.Parse(logDateFormat, f.Until); err == nil {
+		// until is inclusive, the whole day
+		q.Until = t.Add(24*time.Hour - time.Second)
+	} else {
+		f.Until = ""
+	}
+
+	v := url.Values{}
+	for key, value := range map[string]string{
+		"author":    f.Author,
+		"committer": f.Committer,
+		"since":     f.Since,
+		"until":     f.Until,
+		"grep":      f.Grep,
+	} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if f.FirstParent {
+		v.Set("first_parent", "1")
+	}
+	if f.NoMerges {
+		v.Set("no_merges", "1")
+	}
+	f.query = v
+
+	return f, q
 }
 
 func (h *handlers) logHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
 	ref := h.parseRef(r.PathValue("ref"))
-	after := r.URL.Query().Get("after")
+	treePath := strings.Trim(r.PathValue("rest"), "/")
+	filter, query := parseLogFilter(r)
+	query.Path = treePath
 
 	repo, err := h.openPublicRepo(name, ref)
 	if err != nil {
@@ -284,36 +604,276 @@ func (h *handlers) logHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	commits, err := repo.Commits(after)
+	commits, err := repo.Log(r.Context(), query)
 	if err != nil {
+		if errors.Is(err, git.ErrFileNotFound) {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
 		h.write500(w, err)
 		return
 	}
 
 	// if we got full page of commits, we probably have more.
 	// NOTE: this has an edge case, when last page is len(git.CommitsPage), "load more" would be shown
-	nextAfter := ""
+	nextURL := ""
 	if len(commits) == git.CommitsPage && len(commits) > 0 {
-		nextAfter = commits[len(commits)-1].HashShort
+		nextURL = filter.withQuery("", "after", commits[len(commits)-1].HashShort)
 	}
 
 	h.templ(w, "repo_log", h.pageData(repo, RepoLog{
+		Desc:        desc,
+		Ref:         ref,
+		Commits:     commits,
+		NextURL:     nextURL,
+		FeedURL:     filter.withQuery(path.Join("/", name, "feed/log", url.PathEscape(ref), treePath)),
+		Path:        treePath,
+		Breadcrumbs: Breadcrumbs(treePath),
+		Filter:      filter,
+	}))
+}
+
+type RepoSearch struct {
+	Desc      string
+	Ref       string
+	Query     string
+	Regexp    bool
+	MatchCase bool
+	Path      string
+	Files     []SearchFile
+	Matches   int
+	Truncated bool
+	TimedOut  bool
+	Error     string // shown to the user, e.g. invalid regexp
+}
+
+type SearchFile struct {
+	Repo  string // only set in global search
+	Path  string
+	Lines []SearchLine
+}
+
+type SearchLine struct {
+	Number int
+	HTML   template.HTML
+}
+
+func (h *handlers) searchHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	ref := h.parseRef(r.PathValue("ref"))
+	params := r.URL.Query()
+
+	repo, err := h.openPublicRepo(name, ref)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
+
+	desc, err := repo.Description()
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	p := RepoSearch{
 		Desc:      desc,
 		Ref:       ref,
-		Commits:   commits,
-		NextAfter: nextAfter,
-	}))
+		Query:     params.Get("q"),
+		Regexp:    params.Get("re") != "",
+		MatchCase: params.Get("case") != "",
+		Path:      strings.TrimSpace(params.Get("path")),
+	}
+
+	res, err := repo.Grep(r.Context(), git.GrepOptions{
+		Pattern:   p.Query,
+		Regexp:    p.Regexp,
+		MatchCase: p.MatchCase,
+		Paths:     strings.Fields(p.Path),
+	})
+	if err != nil {
+		if !errors.Is(err, git.ErrInvalidPattern) {
+			h.write500(w, err)
+			return
+		}
+		p.Error = err.Error()
+		res = &git.GrepResult{}
+	}
+
+	p.Truncated, p.TimedOut = res.Truncated, res.TimedOut
+	re := searchMarkRegexp(p.Query, p.Regexp, p.MatchCase)
+	for _, f := range res.Files {
+		sf := SearchFile{Path: f.Path}
+		for _, m := range f.Matches {
+			sf.Lines = append(sf.Lines, SearchLine{
+				Number: m.Line,
+				HTML:   markMatches(re, m.Content),
+			})
+		}
+		p.Matches += len(f.Matches)
+		p.Files = append(p.Files, sf)
+	}
+
+	h.templ(w, "repo_search", h.pageData(repo, p))
+}
+
+// searchMarkRegexp returns regexp for highlighting matches of the query, or
+// nil if git's regexp isn't valid in go.
+func searchMarkRegexp(query string, isRegexp, matchCase bool) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	if !isRegexp {
+		query = regexp.QuoteMeta(query)
+	}
+	if !matchCase {
+		query = "(?i)" + query
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+func markMatches(re *regexp.Regexp, line string) template.HTML {
+	if re == nil {
+		return template.HTML(html.EscapeString(line))
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(line, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		sb.WriteString(html.EscapeString(line[last:m[0]]))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(line[m[0]:m[1]]))
+		sb.WriteString("</mark>")
+		last = m[1]
+	}
+	sb.WriteString(html.EscapeString(line[last:]))
+	return template.HTML(sb.String())
 }
 
 type RepoCommit struct {
-	Diff *git.NiceDiff
-	Ref  string
-	Desc string
+	Diff   *git.NiceDiff
+	Ref    string
+	Desc   string
+	Split  bool
+	Views  []DiffLink
+	Parent string     // short hash of the parent diff is taken against
+	Merge  []DiffLink // diffs of merge commit, nil for other commits
+}
+
+// DiffLink is a link to another way of showing the diff.
+type DiffLink struct {
+	Name   string
+	URL    string
+	Active bool
+}
+
+const diffViewCookie = "diff_view"
+
+// splitView reports whether diff should be shown side by side. View picked
+// with ?view=split or ?view=unified is remembered in a cookie.
+func splitView(w http.ResponseWriter, r *http.Request) bool {
+	view := r.URL.Query().Get("view")
+	switch view {
+	case "split", "unified":
+		http.SetCookie(w, &http.Cookie{
+			Name:     diffViewCookie,
+			Value:    view,
+			Path:     "/",
+			MaxAge:   365 * 24 * 60 * 60,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	default:
+		if c, err := r.Cookie(diffViewCookie); err == nil {
+			view = c.Value
+		}
+	}
+	return view == "split"
+}
+
+// diffViews returns links to switch between unified and split views,
+// keeping other query params.
+func diffViews(params url.Values, split bool) []DiffLink {
+	return []DiffLink{
+		{Name: "unified", URL: withParam(params, "view", "unified"), Active: !split},
+		{Name: "split", URL: withParam(params, "view", "split"), Active: split},
+	}
+}
+
+// diffParent parses which parent of a merge commit the diff is taken against,
+// ?parent=N, or ?diff=remerge. Combined diff is the default.
+func diffParent(params url.Values) (int, error) {
+	if params.Get("diff") == "remerge" {
+		return git.DiffRemerge, nil
+	}
+
+	p := params.Get("parent")
+	if p == "" {
+		return git.DiffCombined, nil
+	}
+	n, err := strconv.Atoi(p)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: %q", git.ErrNoParent, p)
+	}
+	return n, nil
+}
+
+// mergeDiffs returns links to combined diff of a merge commit, diffs
+// against each of its parents, and remerge diff.
+func mergeDiffs(params url.Values, parents []string, parent int) []DiffLink {
+	base := maps.Clone(params)
+	base.Del("parent")
+	base.Del("diff")
+
+	links := []DiffLink{{Name: "combined", URL: "?" + base.Encode(), Active: parent == git.DiffCombined}}
+	for i, p := range parents {
+		links = append(links, DiffLink{
+			Name:   "parent " + p,
+			URL:    withParam(base, "parent", strconv.Itoa(i+1)),
+			Active: parent == i+1,
+		})
+	}
+	return append(links, DiffLink{
+		Name:   "merge changes",
+		URL:    withParam(base, "diff", "remerge"),
+		Active: parent == git.DiffRemerge,
+	})
 }
 
 func (h *handlers) commitHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
-	ref := h.parseRef(r.PathValue("ref"))
+	ref, format := cutPatchFormat(r.PathValue("ref"))
+	ref = h.parseRef(ref)
+	params := r.URL.Query()
+
+	if format != "" {
+		repo, err := h.openPublicRepo(name, ref)
+		if err != nil {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
+
+		filename := fmt.Sprintf("%.7s.%s", repo.Hash(), format)
+		h.writePatch(w, filename, func(w io.Writer) error {
+			if format == "diff" {
+				return repo.RawDiff(r.Context(), w)
+			}
+			return repo.Patch(r.Context(), w)
+		})
+		return
+	}
+
+	parent, err := diffParent(params)
+	if err != nil {
+		h.write404(w, r.URL.Path, err)
+		return
+	}
 
 	repo, err := h.openPublicRepo(name, ref)
 	if err != nil {
@@ -321,8 +881,12 @@ func (h *handlers) commitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	diff, err := h.getDiff(repo, ref)
+	diff, err := h.getDiff(r.Context(), repo, ref, parent)
 	if err != nil {
+		if errors.Is(err, git.ErrNoParent) {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
 		h.write500(w, err)
 		return
 	}
@@ -333,49 +897,199 @@ func (h *handlers) commitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.templ(w, "repo_commit", h.pageData(repo, RepoCommit{
-		Desc: desc,
-		Ref:  ref,
-		Diff: diff,
-	}))
+	p := RepoCommit{
+		Desc:  desc,
+		Ref:   ref,
+		Diff:  diff,
+		Split: splitView(w, r),
+	}
+	p.Views = diffViews(params, p.Split)
+	if len(diff.Parents) > 0 {
+		p.Parent = diff.Parents[max(parent, 1)-1]
+	}
+	if len(diff.Parents) > 1 {
+		p.Merge = mergeDiffs(params, diff.Parents, parent)
+	}
+	h.templ(w, "repo_commit", h.pageData(repo, p))
 }
 
 type RepoCompare struct {
-	Desc    string
-	Ref     string
-	Compare *git.Compare
+	Desc     string
+	Ref      string
+	Compare  *git.Compare // nil if only the form is shown
+	Split    bool
+	Views    []DiffLink
+	Dots     []DiffLink // two-dot and three-dot ranges
+	RangeURL string
+	Form     CompareForm
+}
+
+// CompareForm is the form to pick refs to compare.
+type CompareForm struct {
+	Base, Head string
+	TwoDot     bool
+	Refs       []RefGroup
+}
+
+type RefGroup struct {
+	Label string
+	Names []string
+}
+
+// compareURL returns url of the compare page for the range.
+func compareURL(name string, rng git.Range) string {
+	rng.Base, rng.Head = url.PathEscape(rng.Base), url.PathEscape(rng.Head)
+	return fmt.Sprintf("/%s/compare/%s", name, rng)
 }
 
 func (h *handlers) compareHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
-	ref1 := h.parseRef(r.PathValue("ref1"))
-	ref2 := h.parseRef(r.PathValue("ref2"))
+	params := r.URL.Query()
+	spec, format := cutPatchFormat(r.PathValue("range"))
+
+	// submitted form
+	if base, head := params.Get("base"), params.Get("head"); base != "" && head != "" {
+		rng := git.Range{Base: base, Head: head, TwoDot: params.Get("dots") == "2"}
+		http.Redirect(w, r, compareURL(name, rng), http.StatusFound)
+		return
+	}
+
+	rng, ok := git.ParseRange(h.parseRef(spec))
+	if !ok && spec != "" {
+		// old /{name}/compare/{ref1}/{ref2} urls, refs with slashes are escaped in them
+		parts := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+		if len(parts) != 4 {
+			h.write404(w, r.URL.Path, fmt.Errorf("invalid range %q", spec))
+			return
+		}
+
+		ref2, format := cutPatchFormat(parts[3])
+		target := compareURL(name, git.Range{Base: h.parseRef(parts[2]), Head: h.parseRef(ref2)})
+		if format != "" {
+			target += "." + format
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
 
-	repo, err := h.openPublicRepo(name, ref2)
+	repo, err := h.openPublicRepo(name, rng.Head)
 	if err != nil {
 		h.write404(w, r.URL.Path, err)
 		return
 	}
 
+	if format != "" {
+		filename := fmt.Sprintf("%s.%s", strings.ReplaceAll(rng.String(), "/", "-"), format)
+		if _, err := h.openPublicRepo(name, rng.Base); err != nil {
+			h.write404(w, r.URL.Path, err)
+			return
+		}
+
+		h.writePatch(w, filename, func(w io.Writer) error {
+			if format == "diff" {
+				return repo.RawDiffBetween(r.Context(), rng, w)
+			}
+			return repo.PatchSeries(r.Context(), rng, w)
+		})
+		return
+	}
+
 	desc, err := repo.Description()
 	if err != nil {
 		h.write500(w, err)
 		return
 	}
 
-	compare, err := repo.Compare(ref1, ref2)
+	form, err := compareForm(repo, rng)
+	if err != nil {
+		h.write500(w, err)
+		return
+	}
+
+	if spec == "" {
+		h.templ(w, "repo_compare", h.pageData(repo, RepoCompare{
+			Desc: desc,
+			Ref:  form.Head,
+			Form: form,
+		}))
+		return
+	}
+
+	compare, err := repo.Compare(r.Context(), rng, h.diffOptions())
 	if err != nil {
 		h.write404(w, r.URL.Path, err)
 		return
 	}
 
+	highlightDiff(repo, compare.Diff)
+	split := splitView(w, r)
+
+	threeDot, twoDot := rng, rng
+	threeDot.TwoDot, twoDot.TwoDot = false, true
 	h.templ(w, "repo_compare", h.pageData(repo, RepoCompare{
 		Desc:    desc,
-		Ref:     ref2,
+		Ref:     rng.Head,
 		Compare: compare,
+		Split:   split,
+		Views:   diffViews(params, split),
+		Dots: []DiffLink{
+			{Name: "since merge base (...)", URL: compareURL(name, threeDot), Active: !rng.TwoDot},
+			{Name: "since base (..)", URL: compareURL(name, twoDot), Active: rng.TwoDot},
+		},
+		RangeURL: compareURL(name, rng),
+		Form:     form,
 	}))
 }
 
+// compareForm fills the form with branches and tags of the repo. Refs of the
+// range, which aren't branches or tags, e.g. hashes, are listed separately.
+// Empty range defaults to the default branch.
+func compareForm(repo *git.Repo, rng git.Range) (CompareForm, error) {
+	if rng.Base == "" {
+		master, err := repo.DefaultBranch()
+		if err != nil {
+			return CompareForm{}, err
+		}
+		rng.Base, rng.Head = master, master
+	}
+
+	branches, err := repo.Branches()
+	if err != nil {
+		return CompareForm{}, err
+	}
+	tags, _ := repo.Tags()
+
+	form := CompareForm{Base: rng.Base, Head: rng.Head, TwoDot: rng.TwoDot}
+	known := make(map[string]bool)
+	group := RefGroup{Label: "branches"}
+	for _, b := range branches {
+		group.Names = append(group.Names, b.Name)
+		known[b.Name] = true
+	}
+	form.Refs = append(form.Refs, group)
+
+	if len(tags) > 0 {
+		group = RefGroup{Label: "tags"}
+		for _, t := range tags {
+			group.Names = append(group.Names, t.Name())
+			known[t.Name()] = true
+		}
+		form.Refs = append(form.Refs, group)
+	}
+
+	group = RefGroup{Label: "other"}
+	for _, ref := range []string{rng.Base, rng.Head} {
+		if !known[ref] {
+			group.Names = append(group.Names, ref)
+			known[ref] = true
+		}
+	}
+	if len(group.Names) > 0 {
+		form.Refs = append(form.Refs, group)
+	}
+	return form, nil
+}
+
 type RepoRefs struct {
 	Desc     string
 	Ref      string
@@ -422,7 +1136,9 @@ func (h *handlers) refsHandler(w http.ResponseWriter, r *http.Request) {
 type repoList struct {
 	Name       string
 	Desc       string
+	Section    string
 	LastCommit time.Time
+	Created    time.Time // time of the first commit, only set by setCreated
 }
 
 func (h *handlers) listPublicRepos() ([]repoList, error) {
@@ -461,9 +1177,16 @@ func (h *handlers) listPublicRepos() ([]repoList, error) {
 			continue
 		}
 
+		section, err := repo.Section()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
 		repos = append(repos, repoList{
 			Name:       repo.Name(),
 			Desc:       desc,
+			Section:    section,
 			LastCommit: lastCommit.Committed,
 		})
 	}
@@ -476,22 +1199,93 @@ func (h *handlers) listPublicRepos() ([]repoList, error) {
 	return repos, errors.Join(errs...)
 }
 
-func (h handlers) getDiff(r *git.Repo, ref string) (*git.NiceDiff, error) {
-	cacheKey := fmt.Sprintf("%s:%s", r.Name(), ref)
+// cutPatchFormat cuts .patch or .diff extension off the ref, and returns it
+// without the dot.
+func cutPatchFormat(ref string) (string, string) {
+	for _, format := range []string{"patch", "diff"} {
+		if r, ok := strings.CutSuffix(ref, "."+format); ok && r != "" {
+			return r, format
+		}
+	}
+	return ref, ""
+}
+
+// writePatch serves output of write as plain text, so it's shown in browser,
+// and can be piped to git am, or git apply.
+func (h *handlers) writePatch(w http.ResponseWriter, filename string, write func(io.Writer) error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Security-Policy", cmp.Or(h.c.Server.Headers.RawCSP, "sandbox"))
+	w.WriteHeader(http.StatusOK)
+
+	if err := write(w); err != nil {
+		slog.Error("git: patch", "file", filename, "err", err)
+	}
+}
+
+func (h handlers) getDiff(ctx context.Context, r *git.Repo, ref string, parent int) (*git.NiceDiff, error) {
+	cacheKey := fmt.Sprintf("%s:%s:%d", r.Name(), ref, parent)
 	if v, found := h.diffCache.Get(cacheKey); found {
 		return v, nil
 	}
 
-	diff, err := r.Diff()
+	diff, err := r.Diff(ctx, parent, h.diffOptions())
 	if err != nil {
 		return nil, err
 	}
+	highlightDiff(r, diff)
 
 	h.diffCache.Set(cacheKey, diff)
 	return diff, nil
 }
 
-func (h *handlers) renderReadme(r *git.Repo, ref, treePath string) (template.HTML, error) {
+func (h handlers) diffOptions() git.DiffOptions {
+	return git.DiffOptions{
+		MaxFileLines: h.c.Diff.MaxFileLines,
+		MaxFileBytes: h.c.Diff.MaxFileBytes,
+		MaxLines:     h.c.Diff.MaxLines,
+		MaxBytes:     h.c.Diff.MaxBytes,
+		Similarity:   h.c.Diff.Similarity,
+	}
+}
+
+// highlightDiff highlights text fragments of the diff, language of each file
+// is picked from its new name.
+func highlightDiff(r *git.Repo, nd *git.NiceDiff) {
+	if nd == nil {
+		return
+	}
+
+	attrs := r.Attributes()
+	for i := range nd.Diff {
+		d := &nd.Diff[i]
+		name := cmp.Or(d.Name.New, d.Name.Old)
+
+		// first line of the file, to detect language by shebang
+		var first string
+		if len(d.TextFragments) > 0 && d.TextFragments[0].NewPosition <= 1 &&
+			len(d.TextFragments[0].Lines) > 0 {
+			first = d.TextFragments[0].Lines[0].Line
+		}
+
+		lexer := highlight.Lexer(name, attrs.LinguistLanguage(name), first)
+		for j := range d.TextFragments {
+			tf := &d.TextFragments[j]
+			tf.Highlighted = highlight.Fragment(lexer, tf.Lines)
+			for k, spans := range tf.Changes {
+				if len(spans) > 0 {
+					tf.Highlighted[k] = highlight.Mark(tf.Highlighted[k], func(offset int) bool {
+						return slices.ContainsFunc(spans, func(s git.Span) bool {
+							return s.Start <= offset && offset < s.End
+						})
+					})
+				}
+			}
+		}
+	}
+}
+
+func (h *handlers) renderReadme(ctx context.Context, r *git.Repo, ref, treePath string) (template.HTML, error) {
 	name := r.Name()
 	cacheKey := fmt.Sprintf("%s:%s:%s", name, ref, treePath)
 	if v, found := h.readmeCache.Get(cacheKey); found {
@@ -510,22 +1304,15 @@ func (h *handlers) renderReadme(r *git.Repo, ref, treePath string) (template.HTM
 			continue
 		}
 
-		ext := filepath.Ext(readme)
 		content := fc.String()
 		if len(content) > 0 {
-			switch ext {
-			case ".md", ".markdown", ".mkd":
-				readme, err := markdown.Render(name, ref, fullPath, content)
-				if err != nil {
-					return "", err
-				}
-				return template.HTML(readme), nil
-
-			default:
-				readmeContents = template.HTML(fmt.Sprintf(
-					`<pre class="raw">%s</pre>`, html.EscapeString(content),
-				))
+			if rendered, ok := h.renderMarkup(ctx, name, ref, fullPath, fc.Content); ok {
+				return rendered, nil
 			}
+
+			readmeContents = template.HTML(fmt.Sprintf(
+				`<pre class="raw">%s</pre>`, html.EscapeString(content),
+			))
 			break
 		}
 	}
@@ -534,6 +1321,28 @@ func (h *handlers) renderReadme(r *git.Repo, ref, treePath string) (template.HTM
 	return readmeContents, nil
 }
 
+// renderMarkup renders the file to html, if there's a renderer for it. If
+// rendering fails, the file is shown as is.
+func (h *handlers) renderMarkup(ctx context.Context, name, ref, filePath string, content []byte) (template.HTML, bool) {
+	// only rendered files are cached, so a hit means there's a renderer
+	cacheKey := fmt.Sprintf("markup:%s:%s:%s", name, ref, filePath)
+	if v, found := h.readmeCache.Get(cacheKey); found {
+		return v, true
+	}
+
+	out, ok, err := h.markup.Render(ctx, markup.Context{RepoName: name, Ref: ref, Path: filePath}, content)
+	if err != nil {
+		slog.Error("markup: failed to render", "repo", name, "path", filePath, "err", err)
+		return "", false
+	}
+	if !ok {
+		return "", false
+	}
+
+	h.readmeCache.Set(cacheKey, template.HTML(out))
+	return template.HTML(out), true
+}
+
 func (h handlers) pageData[T any](repo *git.Repo, p T) PageData[T] {
 	var name string
 	var gomod, empty bool
@@ -547,12 +1356,13 @@ func (h handlers) pageData[T any](repo *git.Repo, p T) PageData[T] {
 		P:        p,
 		RepoName: name,
 		Meta: Meta{
-			Title:       h.c.Meta.Title,
-			Description: h.c.Meta.Description,
-			Host:        h.c.Meta.Host,
-			GoMod:       gomod,
-			SSHEnabled:  h.c.SSH.Enable,
-			IsEmpty:     empty,
+			Title:         h.c.Meta.Title,
+			Description:   h.c.Meta.Description,
+			Host:          h.c.Meta.Host,
+			GoMod:         gomod,
+			SSHEnabled:    h.c.SSH.Enable,
+			SearchEnabled: h.search != nil,
+			IsEmpty:       empty,
 		},
 	}
 }
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"olexsmir.xyz/mugit/internal/search"
+)
+
+type Search struct {
+	Query     string
+	Regexp    bool
+	MatchCase bool
+	Repo      string
+	AllURL    string // results from all repos, when filtered by repo
+	Facets    []SearchFacet
+	Files     []SearchFile
+	Matches   int
+	Truncated bool
+	TimedOut  bool
+	Error     string
+}
+
+type SearchFacet struct {
+	search.RepoFacet
+	URL      string
+	IsActive bool
+}
+
+// globalSearchHandler searches all public repos. There's no authentication
+// in web interface, so private repos are never searched.
+func (h *handlers) globalSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if h.search == nil {
+		h.write404(w, r.URL.Path, errors.New("search is disabled"))
+		return
+	}
+
+	params := r.URL.Query()
+	p := Search{
+		Query:     params.Get("q"),
+		Regexp:    params.Get("re") != "",
+		MatchCase: params.Get("case") != "",
+		Repo:      strings.TrimSpace(params.Get("repo")),
+	}
+
+	res, err := h.search.Search(r.Context(), search.Query{
+		Pattern:   p.Query,
+		Regexp:    p.Regexp,
+		MatchCase: p.MatchCase,
+		Repo:      p.Repo,
+	})
+	if err != nil {
+		if !errors.Is(err, search.ErrInvalidPattern) {
+			h.write500(w, err)
+			return
+		}
+		p.Error = err.Error()
+		res = &search.Result{}
+	}
+
+	p.Matches, p.Truncated, p.TimedOut = res.Matches, res.Truncated, res.TimedOut
+	p.AllURL = withParam(params, "repo", "")
+	for _, f := range res.Repos {
+		p.Facets = append(p.Facets, SearchFacet{
+			RepoFacet: f,
+			URL:       withParam(params, "repo", f.Name),
+			IsActive:  f.Name == p.Repo,
+		})
+	}
+
+	re := searchMarkRegexp(p.Query, p.Regexp, p.MatchCase)
+	for _, f := range res.Files {
+		sf := SearchFile{Repo: f.Repo, Path: f.Path}
+		for _, m := range f.Matches {
+			sf.Lines = append(sf.Lines, SearchLine{
+				Number: m.Line,
+				HTML:   markMatches(re, m.Content),
+			})
+		}
+		p.Files = append(p.Files, sf)
+	}
+
+	h.templ(w, "search", h.pageData(nil, p))
+}
+
+// withParam returns query string with the param set, or removed if value is empty.
+func withParam(params url.Values, key, value string) string {
+	v := maps.Clone(params)
+	if value == "" {
+		v.Del(key)
+	} else {
+		v.Set(key, value)
+	}
+	return "?" + v.Encode()
+}
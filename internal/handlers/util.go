@@ -37,6 +37,27 @@ func (h *handlers) recoverMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// headersMiddleware sets security headers from config, empty ones are
+// skipped. Handlers serving untrusted content can override them.
+func (h *handlers) headersMiddleware(next http.Handler) http.Handler {
+	headers := map[string]string{
+		"Content-Security-Policy":   h.c.Server.Headers.CSP,
+		"X-Frame-Options":           h.c.Server.Headers.FrameOptions,
+		"Referrer-Policy":           h.c.Server.Headers.ReferrerPolicy,
+		"Permissions-Policy":        h.c.Server.Headers.PermissionsPolicy,
+		"Strict-Transport-Security": h.c.Server.Headers.HSTS,
+		"X-Content-Type-Options":    "nosniff",
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			if v != "" {
+				w.Header().Set(k, v)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (h *handlers) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
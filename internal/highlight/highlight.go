@@ -0,0 +1,239 @@
+package highlight
+
+import (
+	"html"
+	"html/template"
+	"path"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// MaxSize is the max size of content that gets highlighted, bigger files
+// are shown as plain text, since lexing them takes too long.
+const MaxSize = 512 * 1024
+
+// Lexer picks lexer for a file by its language (from linguist-language in
+// .gitattributes) if set, otherwise by the file name, or shebang. Returns
+// nil if none matches.
+func Lexer(filename, language, content string) chroma.Lexer {
+	if language != "" {
+		if l := lexers.Get(language); l != nil {
+			return chroma.Coalesce(l)
+		}
+	}
+
+	if l := lexers.Match(path.Base(filename)); l != nil {
+		return chroma.Coalesce(l)
+	}
+
+	if interp := shebangInterpreter(content); interp != "" {
+		if l := lexers.Get(interp); l != nil {
+			return chroma.Coalesce(l)
+		}
+		// python3.12 -> python
+		if l := lexers.Get(strings.TrimRight(interp, "0123456789.")); l != nil {
+			return chroma.Coalesce(l)
+		}
+	}
+
+	return nil
+}
+
+// shebangInterpreter returns name of the interpreter from the shebang line,
+// e.g. "python3" for "#!/usr/bin/env python3".
+func shebangInterpreter(content string) string {
+	line, ok := strings.CutPrefix(content, "#!")
+	if !ok {
+		return ""
+	}
+	line, _, _ = strings.Cut(line, "\n")
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interp := path.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = path.Base(f)
+				break
+			}
+		}
+	}
+	return interp
+}
+
+// Lines highlights content, and returns html of each of its lines (without
+// trailing newlines). If lexer is nil, or content is too big, lines are
+// only escaped.
+func Lines(lexer chroma.Lexer, content string) []template.HTML {
+	n := strings.Count(content, "\n") + 1
+	if lexer == nil || len(content) > MaxSize {
+		return plainLines(content)
+	}
+
+	iter, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return plainLines(content)
+	}
+
+	out := make([]template.HTML, 0, n)
+	for _, line := range chroma.SplitTokensIntoLines(iter.Tokens()) {
+		if len(out) == n {
+			break
+		}
+
+		var sb strings.Builder
+		for _, tok := range line {
+			value := strings.TrimSuffix(tok.Value, "\n")
+			if value == "" {
+				continue
+			}
+
+			class := classFor(tok.Type)
+			if class == "" {
+				sb.WriteString(html.EscapeString(value))
+				continue
+			}
+			sb.WriteString(`<span class="` + class + `">`)
+			sb.WriteString(html.EscapeString(value))
+			sb.WriteString(`</span>`)
+		}
+		out = append(out, template.HTML(sb.String()))
+	}
+
+	// lexers may drop the trailing empty line
+	for len(out) < n {
+		out = append(out, "")
+	}
+	return out
+}
+
+// Fragment highlights lines of a diff hunk. Old and new sides of the hunk are
+// highlighted separately, so each of them is lexed as continuous code.
+func Fragment(lexer chroma.Lexer, lines []gitdiff.Line) []template.HTML {
+	var oldSide, newSide []string
+	for _, l := range lines {
+		text := strings.TrimSuffix(l.Line, "\n")
+		switch l.Op {
+		case gitdiff.OpDelete:
+			oldSide = append(oldSide, text)
+		case gitdiff.OpAdd:
+			newSide = append(newSide, text)
+		default:
+			oldSide = append(oldSide, text)
+			newSide = append(newSide, text)
+		}
+	}
+
+	oldHL := Lines(lexer, strings.Join(oldSide, "\n"))
+	newHL := Lines(lexer, strings.Join(newSide, "\n"))
+
+	out := make([]template.HTML, len(lines))
+	var o, n int
+	for i, l := range lines {
+		switch l.Op {
+		case gitdiff.OpDelete:
+			out[i] = oldHL[o]
+			o++
+		case gitdiff.OpAdd:
+			out[i] = newHL[n]
+			n++
+		default:
+			out[i] = newHL[n]
+			o++
+			n++
+		}
+
+		if strings.HasSuffix(l.Line, "\n") {
+			out[i] += "\n"
+		}
+	}
+	return out
+}
+
+// Mark wraps changed bytes of a highlighted line in <span class="diff-word">.
+// changed is called with offset of each byte of the line's source text.
+func Mark(line template.HTML, changed func(offset int) bool) template.HTML {
+	s := string(line)
+
+	var sb strings.Builder
+	var marked bool
+	toggle := func(mark bool) {
+		if mark == marked {
+			return
+		}
+		if mark {
+			sb.WriteString(`<span class="diff-word">`)
+		} else {
+			sb.WriteString(`</span>`)
+		}
+		marked = mark
+	}
+
+	for i, offset := 0, 0; i < len(s); {
+		// tags of tokens aren't nested, mark is closed before them, and is
+		// reopened after, so it's never overlapping
+		if s[i] == '<' {
+			toggle(false)
+			end := strings.IndexByte(s[i:], '>') + i + 1
+			sb.WriteString(s[i:end])
+			i = end
+			continue
+		}
+
+		// escaped character is a single byte of source
+		size := 1
+		if s[i] == '&' {
+			size = strings.IndexByte(s[i:], ';') + 1
+		}
+		toggle(s[i] != '\n' && changed(offset))
+		sb.WriteString(s[i : i+size])
+		i += size
+		offset++
+	}
+	toggle(false)
+	return template.HTML(sb.String())
+}
+
+func plainLines(content string) []template.HTML {
+	lines := strings.Split(content, "\n")
+	out := make([]template.HTML, len(lines))
+	for i, l := range lines {
+		out[i] = template.HTML(html.EscapeString(l))
+	}
+	return out
+}
+
+// classFor maps token type to one of a few css classes, so a theme only
+// needs to define a handful of colors.
+func classFor(t chroma.TokenType) string {
+	switch {
+	case t.InSubCategory(chroma.CommentPreproc), t == chroma.NameDecorator:
+		return "hl-p"
+	case t.InCategory(chroma.Comment):
+		return "hl-c"
+	case t == chroma.KeywordType, t == chroma.NameClass, t == chroma.NameNamespace:
+		return "hl-t"
+	case t.InCategory(chroma.Keyword), t == chroma.NameTag, t == chroma.OperatorWord,
+		t == chroma.GenericHeading, t == chroma.GenericSubheading:
+		return "hl-k"
+	case t.InSubCategory(chroma.LiteralString):
+		return "hl-s"
+	case t.InSubCategory(chroma.LiteralNumber), t == chroma.NameConstant:
+		return "hl-n"
+	case t.InSubCategory(chroma.NameFunction):
+		return "hl-f"
+	case t.InSubCategory(chroma.NameBuiltin):
+		return "hl-b"
+	case t == chroma.NameAttribute, t == chroma.NameProperty:
+		return "hl-a"
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package highlight
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"olexsmir.xyz/x/is"
+)
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		language string
+		content  string
+		want     string
+	}{
+		{"by extension", "main.go", "", "", "Go"},
+		{"nix", "flake.nix", "", "", "Nix"},
+		{"by file name", "path/to/Makefile", "", "", "Makefile"},
+		{"linguist language wins", "file.inc", "php", "", "PHP"},
+		{"shebang", "script", "", "#!/bin/bash\necho hi", "Bash"},
+		{"shebang with env", "script", "", "#!/usr/bin/env -S python3.12 -u\nprint(1)", "Python"},
+		{"unknown", "LICENSE", "", "MIT License", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := Lexer(tt.filename, tt.language, tt.content)
+			if tt.want == "" {
+				is.Equal(t, l, nil)
+				return
+			}
+			if l == nil {
+				t.Fatalf("got no lexer, want %s", tt.want)
+			}
+			is.Equal(t, l.Config().Name, tt.want)
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	t.Run("highlights and escapes", func(t *testing.T) {
+		lines := Lines(Lexer("main.go", "", ""), "package main\n\n// <b>\nvar s = \"x\"")
+		is.Equal(t, len(lines), 4)
+		is.Equal(t, lines[0], template.HTML(`<span class="hl-k">package</span> main`))
+		is.Equal(t, lines[1], template.HTML(""))
+		is.Equal(t, lines[2], template.HTML(`<span class="hl-c">// &lt;b&gt;</span>`))
+		is.Equal(t, strings.Contains(string(lines[3]), `<span class="hl-s">&#34;x&#34;</span>`), true)
+	})
+
+	t.Run("no lexer", func(t *testing.T) {
+		is.Equal(t, Lines(nil, "<a>\nb"), []template.HTML{"&lt;a&gt;", "b"})
+	})
+
+	t.Run("too big", func(t *testing.T) {
+		content := strings.Repeat("x := 1\n", MaxSize/7+1)
+		lines := Lines(Lexer("main.go", "", ""), content)
+		is.Equal(t, lines[0], template.HTML("x := 1"))
+	})
+}
+
+func TestFragment(t *testing.T) {
+	lines := []gitdiff.Line{
+		{Op: gitdiff.OpContext, Line: "func main() {\n"},
+		{Op: gitdiff.OpDelete, Line: "\treturn 1\n"},
+		{Op: gitdiff.OpAdd, Line: "\treturn \"a\"\n"},
+		{Op: gitdiff.OpContext, Line: "}"},
+	}
+
+	out := Fragment(Lexer("main.go", "", ""), lines)
+	is.Equal(t, len(out), 4)
+	is.Equal(t, strings.HasSuffix(string(out[0]), "\n"), true)
+	is.Equal(t, strings.Contains(string(out[1]), `<span class="hl-n">1</span>`), true)
+	is.Equal(t, strings.Contains(string(out[2]), `<span class="hl-s">&#34;a&#34;</span>`), true)
+	is.Equal(t, out[3], template.HTML("}"))
+}
+
+func TestMark(t *testing.T) {
+	between := func(start, end int) func(int) bool {
+		return func(off int) bool { return off >= start && off < end }
+	}
+
+	tests := []struct {
+		name    string
+		line    template.HTML
+		changed func(int) bool
+		want    template.HTML
+	}{
+		{"plain", "x := 1", between(5, 6), `x := <span class="diff-word">1</span>`},
+		{"nothing", "x := 1\n", between(10, 12), "x := 1\n"},
+		{
+			"inside token",
+			`<span class="hl-k">return</span> 1`,
+			between(3, 8),
+			`<span class="hl-k">ret<span class="diff-word">urn</span></span><span class="diff-word"> 1</span>`,
+		},
+		{
+			"escaped",
+			`<span class="hl-s">&#34;a&#34;</span>` + "\n",
+			between(1, 2),
+			`<span class="hl-s">&#34;<span class="diff-word">a</span>&#34;</span>` + "\n",
+		},
+		{"newline", "ab\n", between(1, 3), `a<span class="diff-word">b</span>` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is.Equal(t, Mark(tt.line, tt.changed), tt.want)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package humanize
+
+import (
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{10 << 20, "10.0 MiB"},
+		{3<<30 + 512<<20, "3.5 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			is.Equal(t, Bytes(tt.n), tt.want)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package markup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command renders markup with an external command, that reads the file
+// from stdin and writes html to stdout. Repo name, ref and path of the
+// file are passed in MUGIT_REPO, MUGIT_REF and MUGIT_PATH env variables.
+// The rest of server's environment isn't passed, other than [commandEnv],
+// since commands render content of anyone who can push, and the
+// environment may contain tokens.
+type Command struct {
+	Args    []string
+	Timeout time.Duration
+}
+
+func (c *Command) Render(ctx context.Context, rc Context, source []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Stdin = bytes.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(inheritedEnv(),
+		"MUGIT_REPO="+rc.RepoName,
+		"MUGIT_REF="+rc.Ref,
+		"MUGIT_PATH="+rc.Path,
+	)
+	cmd.WaitDelay = time.Second // don't wait for children holding stdout open
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s: timed out after %s", c.Args[0], c.Timeout)
+		}
+		return "", fmt.Errorf("%s: %w, stderr: %s", c.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// commandEnv are env variables passed to commands from server's environment.
+var commandEnv = []string{"PATH", "HOME", "LANG"}
+
+func inheritedEnv() []string {
+	env := make([]string, 0, len(commandEnv))
+	for _, key := range commandEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
@@ -0,0 +1,106 @@
+package markup
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/markdown"
+)
+
+// Context describes rendered file, renderers use it to resolve relative
+// links and images.
+type Context struct {
+	RepoName string
+	Ref      string
+	Path     string
+}
+
+type Renderer interface {
+	Render(ctx context.Context, rc Context, source []byte) (string, error)
+}
+
+type RendererFunc func(ctx context.Context, rc Context, source []byte) (string, error)
+
+func (f RendererFunc) Render(ctx context.Context, rc Context, source []byte) (string, error) {
+	return f(ctx, rc, source)
+}
+
+// Markdown is the built-in renderer, it's used for .md, .markdown and .mkd
+// files, unless config overrides them.
+var Markdown = RendererFunc(func(_ context.Context, rc Context, source []byte) (string, error) {
+	return markdown.Render(rc.RepoName, rc.Ref, rc.Path, string(source))
+})
+
+// Registry picks renderer for a file by its name, or extension.
+type Registry struct {
+	filenames  map[string]Renderer
+	extensions map[string]Renderer
+	policy     *bluemonday.Policy
+}
+
+// NewRegistry creates registry with the built-in markdown renderer, and
+// external commands from config, which take precedence.
+func NewRegistry(cfg config.MarkupConfig) *Registry {
+	r := &Registry{
+		filenames:  make(map[string]Renderer),
+		extensions: make(map[string]Renderer),
+		policy:     newPolicy(cfg.Sanitize),
+	}
+
+	r.Register(Markdown, []string{".md", ".markdown", ".mkd"}, nil)
+	for _, rc := range cfg.Renderers {
+		r.Register(&Command{Args: rc.Command, Timeout: cfg.Timeout}, rc.Extensions, rc.Filenames)
+	}
+	return r
+}
+
+// Register sets renderer for files with any of the extensions, or names.
+// Both are matched case insensitively.
+func (r *Registry) Register(renderer Renderer, extensions, filenames []string) {
+	for _, ext := range extensions {
+		r.extensions["."+strings.ToLower(strings.TrimPrefix(ext, "."))] = renderer
+	}
+	for _, name := range filenames {
+		r.filenames[strings.ToLower(name)] = renderer
+	}
+}
+
+// Lookup returns renderer for the file, or nil if there's none. File name
+// wins over extension.
+func (r *Registry) Lookup(filePath string) Renderer {
+	name := strings.ToLower(path.Base(filePath))
+	if renderer, ok := r.filenames[name]; ok {
+		return renderer
+	}
+	return r.extensions[path.Ext(name)]
+}
+
+// Render renders the file with its renderer, and sanitizes the output, since
+// READMEs come from anyone who can push. Reports false if there's no
+// renderer for the file.
+func (r *Registry) Render(ctx context.Context, rc Context, source []byte) (string, bool, error) {
+	renderer := r.Lookup(rc.Path)
+	if renderer == nil {
+		return "", false, nil
+	}
+
+	out, err := renderer.Render(ctx, rc, source)
+	if err != nil {
+		return "", false, err
+	}
+	return r.policy.Sanitize(out), true, nil
+}
+
+// RenderMarkdown renders source with the built-in markdown renderer, and
+// sanitizes the output. It's used for texts that aren't files, like tag
+// messages.
+func (r *Registry) RenderMarkdown(ctx context.Context, rc Context, source []byte) (string, error) {
+	out, err := Markdown.Render(ctx, rc, source)
+	if err != nil {
+		return "", err
+	}
+	return r.policy.Sanitize(out), nil
+}
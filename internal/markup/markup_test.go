@@ -0,0 +1,157 @@
+package markup
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/x/is"
+)
+
+func TestRegistry_Lookup(t *testing.T) {
+	org := &Command{Args: []string{"pandoc"}}
+	man := &Command{Args: []string{"mandoc"}}
+	md := &Command{Args: []string{"md"}}
+	r := NewRegistry(config.MarkupConfig{})
+	r.Register(org, []string{"org"}, nil)
+	r.Register(man, []string{".1"}, []string{"manpage"})
+	r.Register(md, nil, []string{"CHANGES.md"})
+
+	tests := []struct {
+		path string
+		want Renderer
+	}{
+		{"docs/guide.org", org},
+		{"README.ORG", org},
+		{"mugit.1", man},
+		{"doc/manpage", man},
+		{"CHANGES.md", md},
+		{"changes.md", md},
+		{"main.go", nil},
+		{"Makefile", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			is.Equal(t, r.Lookup(tt.path), tt.want)
+		})
+	}
+
+	// built-in
+	is.Equal(t, r.Lookup("docs/guide.md") != nil, true)
+}
+
+func TestCommand_Render(t *testing.T) {
+	rc := Context{RepoName: "repo", Ref: "main", Path: "docs/a.txt"}
+
+	t.Run("output", func(t *testing.T) {
+		c := &Command{Args: []string{"cat"}, Timeout: time.Second}
+		out, err := c.Render(t.Context(), rc, []byte("<p>hi</p>"))
+		is.Err(t, err, nil)
+		is.Equal(t, out, "<p>hi</p>")
+	})
+
+	t.Run("passes file in env", func(t *testing.T) {
+		c := &Command{Args: []string{"sh", "-c", `echo "$MUGIT_REPO $MUGIT_REF $MUGIT_PATH"`}, Timeout: time.Second}
+		out, err := c.Render(t.Context(), rc, nil)
+		is.Err(t, err, nil)
+		is.Equal(t, strings.TrimSpace(out), "repo main docs/a.txt")
+	})
+
+	t.Run("doesn't pass server's env", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "secret")
+		c := &Command{Args: []string{"sh", "-c", `echo "token=$GITHUB_TOKEN"; echo "path=$PATH"`}, Timeout: time.Second}
+		out, err := c.Render(t.Context(), rc, nil)
+		is.Err(t, err, nil)
+		is.Equal(t, strings.Contains(out, "token=\n"), true)
+		is.Equal(t, strings.Contains(out, "path="+os.Getenv("PATH")), true)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		c := &Command{Args: []string{"sh", "-c", "echo oops >&2; exit 1"}, Timeout: time.Second}
+		_, err := c.Render(t.Context(), rc, nil)
+		is.Err(t, err, "oops")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		c := &Command{Args: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond}
+		_, err := c.Render(t.Context(), rc, nil)
+		is.Err(t, err, "timed out")
+	})
+}
+
+func TestRegistry_Render(t *testing.T) {
+	source := []byte(strings.Join([]string{
+		`# Title`,
+		``,
+		`<p align="center"><img src="logo.png" onerror="alert(1)"></p>`,
+		``,
+		`<p class="diff" style="color: red">styled</p>`,
+		``,
+		`<script>alert(1)</script>`,
+		``,
+		`- [x] done`,
+		``,
+		`> [!note] Note`,
+		`> text`,
+		``,
+	}, "\n"))
+	rc := Context{RepoName: "repo", Ref: "main", Path: "docs/README.md"}
+
+	t.Run("relaxed", func(t *testing.T) {
+		r := NewRegistry(config.MarkupConfig{Sanitize: config.SanitizeRelaxed})
+		out, ok, err := r.Render(t.Context(), rc, source)
+		is.Err(t, err, nil)
+		is.Equal(t, ok, true)
+		is.Equal(t, strings.Contains(out, "<script"), false)
+		is.Equal(t, strings.Contains(out, "onerror"), false)
+		is.Equal(t, strings.Contains(out, `<h1 id="title">Title</h1>`), true)
+		is.Equal(t, strings.Contains(out, `<p align="center"><img src="/repo/raw/main/docs/logo.png"></p>`), true)
+		is.Equal(t, strings.Contains(out, `<input checked="" disabled="" type="checkbox">`), true)
+		is.Equal(t, strings.Contains(out, `<p>styled</p>`), true)
+		is.Equal(t, strings.Contains(out, `<details data-callout="note" open="">`), true)
+		is.Equal(t, strings.Contains(out, `<div class="callout-content">`), true)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		r := NewRegistry(config.MarkupConfig{Sanitize: config.SanitizeStrict})
+		out, ok, err := r.Render(t.Context(), rc, source)
+		is.Err(t, err, nil)
+		is.Equal(t, ok, true)
+		is.Equal(t, strings.Contains(out, "<script"), false)
+		is.Equal(t, strings.Contains(out, `<p><img src="/repo/raw/main/docs/logo.png"></p>`), true)
+		is.Equal(t, strings.Contains(out, "<input"), false)
+		is.Equal(t, strings.Contains(out, `<details data-callout="note" open="">`), true)
+		is.Equal(t, strings.Contains(out, `<div class="callout-content">`), true)
+	})
+
+	t.Run("sanitizes external commands", func(t *testing.T) {
+		r := NewRegistry(config.MarkupConfig{Timeout: time.Second, Renderers: []config.RendererConfig{
+			{Extensions: []string{".txt"}, Command: []string{"cat"}},
+		}})
+		out, ok, err := r.Render(t.Context(), Context{Path: "a.txt"}, []byte(`<a href="javascript:alert(1)" onclick="x">hi</a>`))
+		is.Err(t, err, nil)
+		is.Equal(t, ok, true)
+		is.Equal(t, out, "hi")
+	})
+
+	t.Run("no renderer", func(t *testing.T) {
+		_, ok, err := NewRegistry(config.MarkupConfig{}).Render(t.Context(), Context{Path: "main.go"}, nil)
+		is.Err(t, err, nil)
+		is.Equal(t, ok, false)
+	})
+}
+
+func TestRegistry_RenderMarkdown(t *testing.T) {
+	r := NewRegistry(config.MarkupConfig{})
+	out, err := r.RenderMarkdown(t.Context(), Context{RepoName: "repo", Ref: "v1.0.0"}, []byte(strings.Join([]string{
+		`Release 1.0`,
+		``,
+		`- see [docs](docs/usage.md)`,
+		`<script>alert(1)</script>`,
+	}, "\n")))
+	is.Err(t, err, nil)
+	is.Equal(t, strings.Contains(out, "<script"), false)
+	is.Equal(t, strings.Contains(out, `<a href="/repo/tree/v1.0.0/docs/usage.md"`), true)
+}
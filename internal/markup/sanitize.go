@@ -0,0 +1,31 @@
+package markup
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"olexsmir.xyz/mugit/internal/config"
+)
+
+// newPolicy returns sanitizer for rendered html. Both policies are based on
+// bluemonday's UGC policy, strict one only additionally keeps markup of
+// callouts, relaxed one also allows alignment, and task lists' checkboxes,
+// which are common in READMEs. Classes and styles aren't kept, they'd either
+// pick up styles of mugit's own ui, or be blocked by server.headers.csp.
+func newPolicy(sanitize string) *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// callouts: <details data-callout="note"><summary>..</summary><div class="callout-content">
+	p.AllowAttrs("data-callout").Matching(regexp.MustCompile(`^[a-zA-Z-]*$`)).OnElements("details")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^callout-content$`)).OnElements("div")
+
+	if sanitize == config.SanitizeStrict {
+		return p
+	}
+
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`(?i)^(left|center|right|justify)$`)).
+		OnElements("p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "img", "td", "th")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+	return p
+}
@@ -0,0 +1,215 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"olexsmir.xyz/mugit/internal/config"
+)
+
+var ErrUnknownForge = errors.New("remote is not hosted on a known forge")
+
+// listPageSize is number of repos requested per page, when listing org's repos.
+const listPageSize = 50
+
+// RepoInfo is repository metadata returned by forge's API.
+type RepoInfo struct {
+	Name           string
+	Description    string
+	HasDescription bool // false if response didn't have description, empty one is cleared
+	DefaultBranch  string
+	CloneURL       string
+	IsPrivate      bool
+}
+
+type forge struct {
+	cfg   config.ForgeConfig
+	token string
+	http  *http.Client
+}
+
+func newForge(cfg config.ForgeConfig, githubToken string) *forge {
+	f := &forge{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+	if cfg.Type == config.ForgeGithub {
+		f.token = githubToken
+	}
+	return f
+}
+
+// forgeFor finds configured forge for the remote url, and returns it with repo's path on the forge.
+func (w *Worker) forgeFor(remoteURL string) (*forge, string, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse remote url: %w", err)
+	}
+
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	for _, f := range w.c.Mirror.Forges {
+		if strings.EqualFold(f.Host, u.Host) {
+			return newForge(f, w.c.Mirror.GithubToken), repoPath, nil
+		}
+	}
+
+	return nil, "", ErrUnknownForge
+}
+
+// RepoInfo fetches metadata of repo by its path on the forge (e.g. owner/repo).
+func (f *forge) RepoInfo(ctx context.Context, repoPath string) (*RepoInfo, error) {
+	switch f.cfg.Type {
+	case config.ForgeGitlab:
+		var resp gitlabProject
+		if err := f.get(ctx, "/projects/"+url.PathEscape(repoPath), &resp); err != nil {
+			return nil, err
+		}
+		return resp.info(), nil
+
+	default: // github and gitea have the same shape of response
+		var resp githubRepo
+		if err := f.get(ctx, "/repos/"+repoPath, &resp); err != nil {
+			return nil, err
+		}
+		return resp.info(), nil
+	}
+}
+
+// ListRepos lists all repos of an org, or of a group (including subgroups) on gitlab.
+func (f *forge) ListRepos(ctx context.Context, org string) ([]RepoInfo, error) {
+	var out []RepoInfo
+	for page := 1; ; page++ {
+		var infos []*RepoInfo
+		switch f.cfg.Type {
+		case config.ForgeGitlab:
+			var resp []gitlabProject
+			endpoint := fmt.Sprintf("/groups/%s/projects?include_subgroups=true&per_page=%d&page=%d",
+				url.PathEscape(org), listPageSize, page)
+			if err := f.get(ctx, endpoint, &resp); err != nil {
+				return nil, err
+			}
+			for _, p := range resp {
+				info := p.info()
+				info.Name = p.nameIn(org)
+				infos = append(infos, info)
+			}
+
+		default:
+			var resp []githubRepo
+			// github calls it per_page, gitea calls it limit
+			endpoint := fmt.Sprintf("/orgs/%s/repos?per_page=%d&limit=%d&page=%d",
+				url.PathEscape(org), listPageSize, listPageSize, page)
+			if err := f.get(ctx, endpoint, &resp); err != nil {
+				return nil, err
+			}
+			for _, r := range resp {
+				infos = append(infos, r.info())
+			}
+		}
+
+		for _, info := range infos {
+			out = append(out, *info)
+		}
+		if len(infos) < listPageSize {
+			return out, nil
+		}
+	}
+}
+
+func (f *forge) get(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(f.cfg.APIURL, "/")+endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if f.token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s api: %w", f.cfg.Type, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s api: unexpected status %s", f.cfg.Type, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s api: decoding response: %w", f.cfg.Type, err)
+	}
+	return nil
+}
+
+// optionalString is a json string, that's set even if it's null, since
+// forges return null for cleared fields.
+type optionalString struct {
+	Value string
+	Set   bool
+}
+
+func (s *optionalString) UnmarshalJSON(data []byte) error {
+	s.Set = true
+	if string(data) == "null" {
+		s.Value = ""
+		return nil
+	}
+	return json.Unmarshal(data, &s.Value)
+}
+
+type githubRepo struct {
+	Name          string         `json:"name"`
+	Description   optionalString `json:"description"`
+	DefaultBranch string         `json:"default_branch"`
+	CloneURL      string         `json:"clone_url"`
+	Private       bool           `json:"private"`
+}
+
+func (r githubRepo) info() *RepoInfo {
+	return &RepoInfo{
+		Name:           r.Name,
+		Description:    r.Description.Value,
+		HasDescription: r.Description.Set,
+		DefaultBranch:  r.DefaultBranch,
+		CloneURL:       r.CloneURL,
+		IsPrivate:      r.Private,
+	}
+}
+
+type gitlabProject struct {
+	Path              string         `json:"path"`
+	PathWithNamespace string         `json:"path_with_namespace"`
+	Description       optionalString `json:"description"`
+	DefaultBranch     string         `json:"default_branch"`
+	HTTPURLToRepo     string         `json:"http_url_to_repo"`
+	Visibility        string         `json:"visibility"`
+}
+
+func (p gitlabProject) info() *RepoInfo {
+	return &RepoInfo{
+		Name:           p.Path,
+		Description:    p.Description.Value,
+		HasDescription: p.Description.Set,
+		DefaultBranch:  p.DefaultBranch,
+		CloneURL:       p.HTTPURLToRepo,
+		IsPrivate:      p.Visibility != "public",
+	}
+}
+
+// nameIn returns name of the project, that's unique within the group, projects
+// of subgroups are prefixed with their path, e.g. group/sub/repo is sub-repo.
+func (p gitlabProject) nameIn(group string) string {
+	rel, ok := strings.CutPrefix(p.PathWithNamespace, strings.Trim(group, "/")+"/")
+	if !ok || rel == "" {
+		return p.Path
+	}
+	return strings.ReplaceAll(rel, "/", "-")
+}
@@ -0,0 +1,125 @@
+package mirror
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/git"
+	"olexsmir.xyz/x/is"
+)
+
+func newForgeStub(t *testing.T, typ string) (*Worker, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/repos/user/repo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"name":"repo","description":"from github","default_branch":"main","private":true}`)
+	})
+	mux.HandleFunc("GET /api/repos/user/cleared", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"name":"cleared","description":null}`)
+	})
+	mux.HandleFunc("GET /api/repos/user/partial", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"name":"partial"}`)
+	})
+	mux.HandleFunc("GET /api/projects/{path}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("path") != "group/sub/repo" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"path":"repo","description":"from gitlab","default_branch":"trunk","visibility":"public"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	return NewWorker(&config.Config{
+		Mirror: config.MirrorConfig{
+			GithubToken: "token",
+			Forges: []config.ForgeConfig{
+				{Host: u.Host, Type: typ, APIURL: srv.URL + "/api"},
+			},
+		},
+	}), srv.URL
+}
+
+func TestForge_RepoInfo(t *testing.T) {
+	t.Run("github", func(t *testing.T) {
+		w, base := newForgeStub(t, config.ForgeGithub)
+		f, repoPath, err := w.forgeFor(base + "/user/repo.git")
+		is.Err(t, err, nil)
+		is.Equal(t, repoPath, "user/repo")
+
+		info, err := f.RepoInfo(t.Context(), repoPath)
+		is.Err(t, err, nil)
+		is.Equal(t, info.Description, "from github")
+		is.Equal(t, info.DefaultBranch, "main")
+		is.Equal(t, info.IsPrivate, true)
+	})
+
+	t.Run("gitlab", func(t *testing.T) {
+		w, base := newForgeStub(t, config.ForgeGitlab)
+		f, repoPath, err := w.forgeFor(base + "/group/sub/repo")
+		is.Err(t, err, nil)
+
+		info, err := f.RepoInfo(t.Context(), repoPath)
+		is.Err(t, err, nil)
+		is.Equal(t, info.Description, "from gitlab")
+		is.Equal(t, info.DefaultBranch, "trunk")
+		is.Equal(t, info.IsPrivate, false)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		w, base := newForgeStub(t, config.ForgeGitea)
+		f, repoPath, err := w.forgeFor(base + "/user/nonexistent")
+		is.Err(t, err, nil)
+
+		_, err = f.RepoInfo(t.Context(), repoPath)
+		is.Err(t, err, "unexpected status 404")
+	})
+
+	t.Run("unknown forge", func(t *testing.T) {
+		w, _ := newForgeStub(t, config.ForgeGithub)
+		_, _, err := w.forgeFor("https://git.example.com/user/repo")
+		is.Err(t, err, ErrUnknownForge)
+	})
+}
+
+func TestWorker_syncDescription(t *testing.T) {
+	w, base := newForgeStub(t, config.ForgeGithub)
+
+	tests := []struct {
+		name     string
+		upstream string
+		want     string
+	}{
+		{name: "set upstream", upstream: "repo", want: "from github"},
+		{name: "cleared upstream", upstream: "cleared", want: ""},
+		{name: "missing in response", upstream: "partial", want: "local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "repo.git")
+			is.Err(t, git.Init(path), nil)
+			repo, err := git.Open(path, "")
+			is.Err(t, err, nil)
+			is.Err(t, repo.SetDescription("local"), nil)
+
+			is.Err(t, w.syncDescription(t.Context(), repo, base+"/user/"+tt.upstream), nil)
+
+			desc, err := repo.Description()
+			is.Err(t, err, nil)
+			is.Equal(t, desc, tt.want)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"olexsmir.xyz/mugit/internal/config"
+)
+
+// ListOrgRepos lists all repos of an org (or a group on gitlab) on a forge of
+// forgeType. If apiURL is empty, the one of configured forge of the same type is used.
+func (w *Worker) ListOrgRepos(ctx context.Context, forgeType, apiURL, org string) ([]RepoInfo, error) {
+	if apiURL == "" {
+		for _, f := range w.c.Mirror.Forges {
+			if f.Type == forgeType {
+				apiURL = f.APIURL
+				break
+			}
+		}
+	}
+	if apiURL == "" {
+		return nil, fmt.Errorf("no api url configured for %s", forgeType)
+	}
+
+	f := newForge(config.ForgeConfig{Type: forgeType, APIURL: apiURL}, w.c.Mirror.GithubToken)
+	return f.ListRepos(ctx, org)
+}
+
+// LookupRepo fetches metadata of a repo by its remote url, returns
+// [ErrUnknownForge] if remote isn't hosted on a configured forge.
+func (w *Worker) LookupRepo(ctx context.Context, remoteURL string) (*RepoInfo, error) {
+	f, repoPath, err := w.forgeFor(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	return f.RepoInfo(ctx, repoPath)
+}
+
+// ParseManifest parses list of repos to mirror. Each line is a remote url,
+// optionally followed by the name of the mirror, empty lines and lines
+// starting with "#" are ignored.
+//
+//	https://github.com/user/repo
+//	https://gitlab.com/group/sub/repo sub-repo
+func ParseManifest(r io.Reader) ([]RepoInfo, error) {
+	var repos []RepoInfo
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected url and optional name", n)
+		}
+
+		remoteURL := fields[0]
+		if err := IsRemoteSupported(remoteURL); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		name, err := nameFromURL(remoteURL)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if len(fields) == 2 {
+			name = fields[1]
+		}
+
+		repos = append(repos, RepoInfo{Name: name, CloneURL: remoteURL})
+	}
+	return repos, sc.Err()
+}
+
+func nameFromURL(remoteURL string) (string, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse remote url: %w", err)
+	}
+
+	name := strings.TrimSuffix(path.Base(strings.TrimSuffix(u.Path, "/")), ".git")
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("can't get repo name from %s", remoteURL)
+	}
+	return name, nil
+}
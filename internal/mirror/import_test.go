@@ -0,0 +1,107 @@
+package mirror
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/x/is"
+)
+
+func TestParseManifest(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		repos, err := ParseManifest(strings.NewReader(`
+# comment
+https://github.com/user/repo.git
+https://gitlab.com/group/sub/repo   sub-repo
+`))
+		is.Err(t, err, nil)
+		is.Equal(t, repos, []RepoInfo{
+			{Name: "repo", CloneURL: "https://github.com/user/repo.git"},
+			{Name: "sub-repo", CloneURL: "https://gitlab.com/group/sub/repo"},
+		})
+	})
+
+	t.Run("unsupported remote", func(t *testing.T) {
+		_, err := ParseManifest(strings.NewReader("git@github.com:user/repo.git"))
+		is.Err(t, err, "line 1: only http and https remotes are supported")
+	})
+
+	t.Run("too many fields", func(t *testing.T) {
+		_, err := ParseManifest(strings.NewReader("\nhttps://github.com/user/repo name extra"))
+		is.Err(t, err, "line 2: expected url and optional name")
+	})
+
+	t.Run("no name", func(t *testing.T) {
+		_, err := ParseManifest(strings.NewReader("https://github.com/"))
+		is.Err(t, err, "can't get repo name")
+	})
+}
+
+func TestWorker_ListOrgRepos(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orgs/myorg/repos", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		count := listPageSize
+		if page == 2 {
+			count = 1
+		}
+
+		var repos []string
+		for i := range count {
+			repos = append(repos, fmt.Sprintf(
+				`{"name":"repo%d-%d","description":"desc","clone_url":"https://example.com/myorg/repo%d-%d.git","private":%t}`,
+				page, i, page, i, i == 0,
+			))
+		}
+		_, _ = fmt.Fprintf(w, "[%s]", strings.Join(repos, ","))
+	})
+	mux.HandleFunc("GET /groups/{group}/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("group") != "group/sub" || r.URL.Query().Get("include_subgroups") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, `[
+			{"path":"repo","path_with_namespace":"group/sub/repo","http_url_to_repo":"https://gitlab.example.com/group/sub/repo.git","visibility":"public"},
+			{"path":"repo","path_with_namespace":"group/sub/a/repo","http_url_to_repo":"https://gitlab.example.com/group/sub/a/repo.git","visibility":"public"},
+			{"path":"repo","path_with_namespace":"group/sub/b/c/repo","http_url_to_repo":"https://gitlab.example.com/group/sub/b/c/repo.git","visibility":"public"}
+		]`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	w := NewWorker(&config.Config{})
+
+	t.Run("paginates", func(t *testing.T) {
+		repos, err := w.ListOrgRepos(t.Context(), config.ForgeGitea, srv.URL, "myorg")
+		is.Err(t, err, nil)
+		is.Equal(t, len(repos), listPageSize+1)
+		is.Equal(t, requests, 2)
+		is.Equal(t, repos[0].IsPrivate, true)
+		is.Equal(t, repos[listPageSize].Name, "repo2-0")
+	})
+
+	t.Run("gitlab group", func(t *testing.T) {
+		repos, err := w.ListOrgRepos(t.Context(), config.ForgeGitlab, srv.URL, "group/sub")
+		is.Err(t, err, nil)
+		is.Equal(t, len(repos), 3)
+		is.Equal(t, repos[0].CloneURL, "https://gitlab.example.com/group/sub/repo.git")
+
+		// projects of subgroups are named by their path in the group
+		is.Equal(t, repos[0].Name, "repo")
+		is.Equal(t, repos[1].Name, "a-repo")
+		is.Equal(t, repos[2].Name, "b-c-repo")
+	})
+
+	t.Run("no api url", func(t *testing.T) {
+		_, err := w.ListOrgRepos(t.Context(), config.ForgeGithub, "", "myorg")
+		is.Err(t, err, "no api url configured for github")
+	})
+}
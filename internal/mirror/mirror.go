@@ -28,92 +28,147 @@ func IsGithubRemote(remoteURL string) bool {
 	return strings.Contains(remoteURL, "github.com")
 }
 
+var (
+	ErrNotMirror    = errors.New("repository is not a mirror")
+	ErrSyncQueued   = errors.New("too many syncs are already queued")
+	ErrSyncInFlight = errors.New("sync is already in progress")
+)
+
+const maxConcurrentSyncs = 10
+
 type Worker struct {
 	c *config.Config
+
+	trigger chan string
+
+	mu      sync.Mutex
+	running map[string]struct{}
+
+	onUpdate func(name string)
 }
 
 func NewWorker(cfg *config.Config) *Worker {
 	return &Worker{
-		c: cfg,
+		c:       cfg,
+		trigger: make(chan string, maxConcurrentSyncs),
+		running: make(map[string]struct{}),
 	}
 }
 
+// Start runs the scheduler until ctx is canceled. Each mirror is synced on
+// its own interval (falls back to mirror.interval), with jitter applied so
+// syncs are spread over time. Start returns after running syncs are
+// stopped.
 func (w *Worker) Start(ctx context.Context) error {
-	ticker := time.NewTicker(w.c.Mirror.Interval)
-	defer ticker.Stop()
+	sched := newSchedule(w.c.Mirror.Interval)
+	sem := semaphore.NewWeighted(maxConcurrentSyncs)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		default:
-			if err := w.mirror(ctx); err != nil {
-				slog.Error("mirror sync failed", "err", err)
+
+		case name := <-w.trigger:
+			repo, err := w.openMirror(name)
+			if err != nil {
+				slog.Error("mirror: triggered sync failed", "repo", name, "err", err)
+				continue
+			}
+
+			// the sync replaces the scheduled one
+			sched.next[repo.Name()] = time.Now().Add(jitter(sched.intervalFor(repo)))
+			wg.Go(func() {
+				// errors are logged by syncRepo
+				_ = w.withSem(ctx, sem, func() error { return w.syncRepo(ctx, repo) })
+			})
+
+		case <-timer.C:
+			repos, err := w.findMirrorRepos()
+			if err != nil {
+				slog.Error("mirror: failed to find mirrors", "err", err)
 			}
 
-			<-ticker.C
+			now := time.Now()
+			for _, repo := range sched.due(repos, now) {
+				wg.Go(func() {
+					// errors are logged by syncRepo
+					_ = w.withSem(ctx, sem, func() error { return w.syncRepo(ctx, repo) })
+				})
+			}
+			timer.Reset(sched.wait(now))
 		}
 	}
 }
 
+// OnUpdate sets function that's called after sync, that changed the mirror.
+// Must be set before [Worker.Start].
+func (w *Worker) OnUpdate(fn func(name string)) {
+	w.onUpdate = fn
+}
+
+// Trigger asks running worker to sync the mirror as soon as possible, its
+// next scheduled sync is counted from now. The sync waits for a free slot,
+// like scheduled ones.
+func (w *Worker) Trigger(name string) error {
+	select {
+	case w.trigger <- git.ResolveName(name):
+		return nil
+	default:
+		return ErrSyncQueued
+	}
+}
+
 func (w *Worker) SyncRepo(ctx context.Context, name string) error {
+	repo, err := w.openMirror(name)
+	if err != nil {
+		return err
+	}
+	return w.syncRepo(ctx, repo)
+}
+
+func (w *Worker) openMirror(name string) (*git.Repo, error) {
 	path, err := git.ResolvePath(w.c.Repo.Dir, git.ResolveName(name))
 	if err != nil {
-		return fmt.Errorf("failed to resolve repo path: %w", err)
+		return nil, fmt.Errorf("failed to resolve repo path: %w", err)
 	}
 
 	repo, err := git.Open(path, "")
 	if err != nil {
-		return fmt.Errorf("failed to open repo: %w", err)
+		return nil, fmt.Errorf("failed to open repo: %w", err)
 	}
 
 	isMirror, err := repo.IsMirror()
 	if err != nil {
-		return fmt.Errorf("failed to check mirror status: %w", err)
+		return nil, fmt.Errorf("failed to check mirror status: %w", err)
 	}
 	if !isMirror {
-		return fmt.Errorf("repository is not a mirror")
+		return nil, ErrNotMirror
 	}
-
-	return w.syncRepo(ctx, repo)
+	return repo, nil
 }
 
-func (w *Worker) mirror(ctx context.Context) error {
-	repos, err := w.findMirrorRepos()
-	if err != nil {
+func (w *Worker) withSem(ctx context.Context, sem *semaphore.Weighted, fn func() error) error {
+	if err := sem.Acquire(ctx, 1); err != nil {
 		return err
 	}
-
-	var wg sync.WaitGroup
-	sem := semaphore.NewWeighted(10)
-	errCh := make(chan error, len(repos))
-
-	for _, repo := range repos {
-		wg.Go(func() {
-			if err := sem.Acquire(ctx, 1); err != nil {
-				errCh <- err
-				return
-			}
-			defer sem.Release(1)
-
-			if err := w.syncRepo(ctx, repo); err != nil {
-				errCh <- err
-			}
-		})
-	}
-
-	wg.Wait()
-	close(errCh)
-
-	var errs []error
-	for err := range errCh {
-		errs = append(errs, err)
-	}
-	return errors.Join(errs...)
+	defer sem.Release(1)
+	return fn()
 }
 
 func (w *Worker) syncRepo(ctx context.Context, repo *git.Repo) error {
 	name := repo.Name()
+	if !w.lock(name) {
+		slog.Info("mirror: sync is already in progress", "repo", name)
+		return ErrSyncInFlight
+	}
+	defer w.unlock(name)
+
 	slog.Info("mirror: sync started", "repo", name)
 
 	remoteURL, err := repo.RemoteURL()
@@ -127,12 +182,15 @@ func (w *Worker) syncRepo(ctx context.Context, repo *git.Repo) error {
 		return err
 	}
 
-	var isUpdated bool
-	if IsGithubRemote(remoteURL) && w.c.Mirror.GithubToken != "" {
-		isUpdated, err = repo.FetchFromGithubWithToken(ctx, w.c.Mirror.GithubToken)
-	} else {
-		isUpdated, err = repo.Fetch(ctx)
+	opts := git.FetchOptions{
+		Timeout:  w.c.Mirror.FetchTimeout,
+		Progress: newProgressLogger(name),
+	}
+	if IsGithubRemote(remoteURL) {
+		opts.GithubToken = w.c.Mirror.GithubToken
 	}
+
+	isUpdated, err := repo.Fetch(ctx, opts)
 	if err != nil {
 		slog.Error("mirror: fetch failed", "repo", name, "err", err)
 		return err
@@ -147,12 +205,62 @@ func (w *Worker) syncRepo(ctx context.Context, repo *git.Repo) error {
 		if err := repo.SetLastSync(now); err != nil {
 			slog.Error("mirror: failed to set last sync time", "repo", name, "err", err)
 		}
+		if w.onUpdate != nil {
+			w.onUpdate(name)
+		}
+	}
+
+	if w.c.Mirror.SyncDescription {
+		if err := w.syncDescription(ctx, repo, remoteURL); err != nil {
+			slog.Error("mirror: failed to sync description", "repo", name, "err", err)
+		}
 	}
 
 	slog.Info("mirror: sync completed", "repo", repo.Name(), "updated", isUpdated)
 	return nil
 }
 
+func (w *Worker) syncDescription(ctx context.Context, repo *git.Repo, remoteURL string) error {
+	f, repoPath, err := w.forgeFor(remoteURL)
+	if errors.Is(err, ErrUnknownForge) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := f.RepoInfo(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+
+	desc, err := repo.Description()
+	if err != nil {
+		return err
+	}
+
+	if !info.HasDescription || info.Description == desc {
+		return nil
+	}
+	return repo.SetDescription(info.Description)
+}
+
+func (w *Worker) lock(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.running[name]; ok {
+		return false
+	}
+	w.running[name] = struct{}{}
+	return true
+}
+
+func (w *Worker) unlock(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.running, name)
+}
+
 func (w *Worker) findMirrorRepos() ([]*git.Repo, error) {
 	dirs, err := os.ReadDir(w.c.Repo.Dir)
 	if err != nil {
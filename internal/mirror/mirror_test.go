@@ -1,8 +1,16 @@
 package mirror
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/git"
 	"olexsmir.xyz/x/is"
 )
 
@@ -61,3 +69,99 @@ func TestIsGithubRemote(t *testing.T) {
 		})
 	}
 }
+
+func TestWorker_Start(t *testing.T) {
+	// upstream hangs, so the sync is still running on shutdown
+	requested := make(chan struct{})
+	var once sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(requested) })
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hanging.git")
+	is.Err(t, git.Init(path), nil)
+	repo, err := git.Open(path, "")
+	is.Err(t, err, nil)
+	is.Err(t, repo.SetMirrorRemote(srv.URL+"/repo.git"), nil)
+
+	cfg := &config.Config{
+		Repo:   config.RepoConfig{Dir: dir},
+		Mirror: config.MirrorConfig{Interval: 10 * time.Millisecond, FetchTimeout: time.Minute},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	done := make(chan error, 1)
+	w := NewWorker(cfg)
+	go func() { done <- w.Start(ctx) }()
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirror wasn't synced")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		is.Err(t, err, nil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker didn't stop after cancel")
+	}
+	is.Equal(t, w.lock("hanging"), true) // sync has finished
+}
+
+func TestWorker_Trigger(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "triggered.git")
+	is.Err(t, git.Init(path), nil)
+	repo, err := git.Open(path, "")
+	is.Err(t, err, nil)
+	is.Err(t, repo.SetMirrorRemote(srv.URL+"/repo.git"), nil)
+	// not due for an hour
+	is.Err(t, repo.SetLastChecked(time.Now()), nil)
+
+	cfg := &config.Config{
+		Repo:   config.RepoConfig{Dir: dir},
+		Mirror: config.MirrorConfig{Interval: time.Hour, FetchTimeout: time.Minute},
+	}
+
+	t.Run("queue is bounded", func(t *testing.T) {
+		w := NewWorker(cfg)
+		for range maxConcurrentSyncs {
+			is.Err(t, w.Trigger("triggered"), nil)
+		}
+		is.Err(t, w.Trigger("triggered"), ErrSyncQueued)
+	})
+
+	t.Run("syncs running worker", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		done := make(chan error, 1)
+		w := NewWorker(cfg)
+		go func() { done <- w.Start(ctx) }()
+
+		is.Err(t, w.Trigger("triggered"), nil)
+		select {
+		case <-requested:
+		case <-time.After(5 * time.Second):
+			t.Fatal("mirror wasn't synced")
+		}
+
+		cancel()
+		is.Err(t, <-done, nil)
+	})
+}
@@ -0,0 +1,45 @@
+package mirror
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval is how often fetch progress of a mirror is logged.
+const progressInterval = 30 * time.Second
+
+// progressLogger logs git's progress output, at most once per
+// [progressInterval], since git redraws its progress many times a second.
+type progressLogger struct {
+	repo string
+
+	mu   sync.Mutex
+	last time.Time
+	now  func() time.Time
+}
+
+func newProgressLogger(repo string) *progressLogger {
+	return &progressLogger{repo: repo, now: time.Now}
+}
+
+func (p *progressLogger) Write(b []byte) (int, error) {
+	line := strings.TrimSpace(string(b))
+	if line == "" {
+		return len(b), nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if now.Sub(p.last) < progressInterval {
+		slog.Debug("mirror: fetch progress", "repo", p.repo, "progress", line)
+		return len(b), nil
+	}
+
+	p.last = now
+	slog.Info("mirror: fetch progress", "repo", p.repo, "progress", line)
+	return len(b), nil
+}
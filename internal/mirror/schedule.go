@@ -0,0 +1,107 @@
+package mirror
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"olexsmir.xyz/mugit/internal/git"
+)
+
+const (
+	// rescanInterval is the max time between looking for new or removed mirrors.
+	rescanInterval = time.Minute
+
+	// startupSpread is the max delay of the first sync for mirrors that are
+	// overdue or never were checked, so they don't all hit upstreams at once.
+	startupSpread = 5 * time.Minute
+)
+
+// schedule keeps track of when each mirror is due to be synced.
+type schedule struct {
+	interval time.Duration // used when repo doesn't set its own
+	next     map[string]time.Time
+}
+
+func newSchedule(interval time.Duration) *schedule {
+	return &schedule{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// due returns mirrors that should be synced now, and schedules their next sync.
+func (s *schedule) due(repos []*git.Repo, now time.Time) []*git.Repo {
+	seen := make(map[string]struct{}, len(repos))
+
+	var out []*git.Repo
+	for _, repo := range repos {
+		name := repo.Name()
+		seen[name] = struct{}{}
+		interval := s.intervalFor(repo)
+
+		next, ok := s.next[name]
+		if !ok {
+			lastChecked, _ := repo.LastChecked()
+			next = firstSync(lastChecked, interval, now)
+			s.next[name] = next
+		}
+
+		if next.After(now) {
+			continue
+		}
+
+		out = append(out, repo)
+		s.next[name] = now.Add(jitter(interval))
+	}
+
+	// forget about repos that were removed, or stopped being mirrors
+	for name := range s.next {
+		if _, ok := seen[name]; !ok {
+			delete(s.next, name)
+		}
+	}
+
+	return out
+}
+
+// wait returns how long to wait until the next mirror is due, capped by [rescanInterval].
+func (s *schedule) wait(now time.Time) time.Duration {
+	out := rescanInterval
+	for _, next := range s.next {
+		out = min(out, next.Sub(now))
+	}
+	return max(out, 0)
+}
+
+func (s *schedule) intervalFor(repo *git.Repo) time.Duration {
+	interval, err := repo.MirrorInterval()
+	if err != nil {
+		slog.Error("mirror: invalid repo interval, using default", "repo", repo.Name(), "err", err)
+		return s.interval
+	}
+	if interval <= 0 {
+		return s.interval
+	}
+	return interval
+}
+
+// firstSync returns when a mirror should be synced for the first time since the worker started.
+func firstSync(lastChecked time.Time, interval time.Duration, now time.Time) time.Time {
+	if !lastChecked.IsZero() {
+		next := lastChecked.Add(interval)
+		if next.After(now) {
+			return next
+		}
+	}
+	return now.Add(rand.N(min(interval, startupSpread)))
+}
+
+// jitter returns interval randomly shifted by up to ±10%.
+func jitter(interval time.Duration) time.Duration {
+	spread := interval / 10
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + rand.N(2*spread)
+}
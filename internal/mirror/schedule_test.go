@@ -0,0 +1,163 @@
+package mirror
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"olexsmir.xyz/mugit/internal/git"
+	"olexsmir.xyz/x/is"
+)
+
+func TestJitter(t *testing.T) {
+	interval := time.Hour
+	for range 100 {
+		got := jitter(interval)
+		if got < interval-interval/10 || got >= interval+interval/10 {
+			t.Fatalf("jitter(%s) = %s, out of ±10%% range", interval, got)
+		}
+	}
+
+	is.Equal(t, jitter(time.Nanosecond), time.Nanosecond)
+}
+
+func TestFirstSync(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("not due yet", func(t *testing.T) {
+		lastChecked := now.Add(-30 * time.Minute)
+		is.Equal(t, firstSync(lastChecked, time.Hour, now), lastChecked.Add(time.Hour))
+	})
+
+	t.Run("overdue is spread", func(t *testing.T) {
+		got := firstSync(now.Add(-48*time.Hour), time.Hour, now)
+		if got.Before(now) || !got.Before(now.Add(startupSpread)) {
+			t.Fatalf("got %s, want within %s from now", got, startupSpread)
+		}
+	})
+
+	t.Run("never checked is spread", func(t *testing.T) {
+		got := firstSync(time.Time{}, time.Hour, now)
+		if got.Before(now) || !got.Before(now.Add(startupSpread)) {
+			t.Fatalf("got %s, want within %s from now", got, startupSpread)
+		}
+	})
+
+	t.Run("spread is capped by interval", func(t *testing.T) {
+		got := firstSync(time.Time{}, time.Second, now)
+		if got.Before(now) || !got.Before(now.Add(time.Second)) {
+			t.Fatalf("got %s, want within a second from now", got)
+		}
+	})
+}
+
+func TestSchedule_wait(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("no mirrors", func(t *testing.T) {
+		s := newSchedule(time.Hour)
+		is.Equal(t, s.wait(now), rescanInterval)
+	})
+
+	t.Run("next mirror is soon", func(t *testing.T) {
+		s := newSchedule(time.Hour)
+		s.next["a"] = now.Add(time.Hour)
+		s.next["b"] = now.Add(10 * time.Second)
+		is.Equal(t, s.wait(now), 10*time.Second)
+	})
+
+	t.Run("overdue mirror", func(t *testing.T) {
+		s := newSchedule(time.Hour)
+		s.next["a"] = now.Add(-time.Minute)
+		is.Equal(t, s.wait(now), time.Duration(0))
+	})
+}
+
+func TestSchedule_due(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		interval    time.Duration // repo's own, 0 uses the default of an hour
+		lastChecked time.Time
+		next        time.Time // zero if not scheduled yet
+		wantDue     bool
+		wantNext    [2]time.Time // [from, to)
+	}{
+		{
+			name:     "due, rescheduled on default interval",
+			next:     now.Add(-time.Minute),
+			wantDue:  true,
+			wantNext: [2]time.Time{now.Add(54 * time.Minute), now.Add(66 * time.Minute)},
+		},
+		{
+			name:     "due, rescheduled on repo interval",
+			interval: 24 * time.Hour,
+			next:     now,
+			wantDue:  true,
+			wantNext: [2]time.Time{now.Add(21*time.Hour + 36*time.Minute), now.Add(26*time.Hour + 24*time.Minute)},
+		},
+		{
+			name:     "not due yet",
+			next:     now.Add(10 * time.Minute),
+			wantNext: [2]time.Time{now.Add(10 * time.Minute), now.Add(10*time.Minute + 1)},
+		},
+		{
+			name:        "first sync counts repo interval from last check",
+			interval:    2 * time.Hour,
+			lastChecked: now.Add(-time.Hour),
+			wantNext:    [2]time.Time{now.Add(time.Hour), now.Add(time.Hour + 1)},
+		},
+		{
+			name:        "first sync counts default interval from last check",
+			lastChecked: now.Add(-10 * time.Minute),
+			wantNext:    [2]time.Time{now.Add(50 * time.Minute), now.Add(50*time.Minute + 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newScheduledRepo(t, "repo", tt.interval, tt.lastChecked)
+			s := newSchedule(time.Hour)
+			if !tt.next.IsZero() {
+				s.next["repo"] = tt.next
+			}
+
+			due := s.due([]*git.Repo{repo}, now)
+			is.Equal(t, len(due) == 1, tt.wantDue)
+
+			next := s.next["repo"]
+			if next.Before(tt.wantNext[0]) || !next.Before(tt.wantNext[1]) {
+				t.Fatalf("next sync at %s, want in [%s, %s)", next, tt.wantNext[0], tt.wantNext[1])
+			}
+		})
+	}
+
+	t.Run("removed mirrors are dropped", func(t *testing.T) {
+		repo := newScheduledRepo(t, "kept", 0, now)
+		s := newSchedule(time.Hour)
+		s.next["removed"] = now
+
+		due := s.due([]*git.Repo{repo}, now)
+		is.Equal(t, len(due), 0)
+		_, ok := s.next["removed"]
+		is.Equal(t, ok, false)
+		_, ok = s.next["kept"]
+		is.Equal(t, ok, true)
+	})
+}
+
+func newScheduledRepo(t *testing.T, name string, interval time.Duration, lastChecked time.Time) *git.Repo {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".git")
+	is.Err(t, git.Init(path), nil)
+	repo, err := git.Open(path, "")
+	is.Err(t, err, nil)
+	if interval > 0 {
+		is.Err(t, repo.SetMirrorInterval(interval), nil)
+	}
+	if !lastChecked.IsZero() {
+		is.Err(t, repo.SetLastChecked(lastChecked), nil)
+	}
+	return repo
+}
@@ -0,0 +1,225 @@
+// Package release stores release assets, files attached to tags, like
+// binaries or tarballs. They're kept next to the repo, outside of git, so
+// they aren't cloned or fetched.
+package release
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+)
+
+// ChecksumsFile is name of the file, in each release, listing sha256 sums of
+// its assets, in format of sha256sum(1).
+const ChecksumsFile = "SHA256SUMS"
+
+var (
+	ErrNotFound    = errors.New("release asset not found")
+	ErrInvalidName = errors.New("invalid asset name")
+	ErrInvalidTag  = errors.New("invalid tag name")
+)
+
+type Asset struct {
+	Name     string
+	Size     int64
+	SHA256   string
+	Uploaded time.Time
+}
+
+// Store keeps assets of one repo, in <repo>/mugit-releases/<tag>/.
+type Store struct {
+	dir string
+}
+
+// NewStore creates store for the repo at repoPath.
+func NewStore(repoPath string) *Store {
+	return &Store{dir: filepath.Join(repoPath, "mugit-releases")}
+}
+
+// Assets returns assets of the tag, sorted by name. Tags without assets
+// have none, and that isn't an error.
+func (s *Store) Assets(tag string) ([]Asset, error) {
+	dir, err := s.tagDir(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := readChecksums(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]Asset, 0, len(sums))
+	for name, sum := range sums {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		assets = append(assets, Asset{
+			Name:     name,
+			Size:     info.Size(),
+			SHA256:   sum,
+			Uploaded: info.ModTime(),
+		})
+	}
+
+	slices.SortFunc(assets, func(a, b Asset) int { return strings.Compare(a.Name, b.Name) })
+	return assets, nil
+}
+
+// Add stores content of r as asset of the tag, replacing asset with the
+// same name, and records its checksum.
+func (s *Store) Add(tag, name string, r io.Reader) (Asset, error) {
+	if !isValidName(name) {
+		return Asset{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	dir, err := s.tagDir(tag)
+	if err != nil {
+		return Asset{}, err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return Asset{}, err
+	}
+
+	// write to a temp file first, so the asset isn't served half written
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return Asset{}, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Asset{}, fmt.Errorf("writing %s: %w", name, err)
+	}
+
+	// assets are served to anyone, the temp file is created private
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return Asset{}, err
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return Asset{}, err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err = updateChecksums(dir, func(sums map[string]string) { sums[name] = sum }); err != nil {
+		return Asset{}, err
+	}
+
+	return Asset{Name: name, Size: size, SHA256: sum, Uploaded: time.Now()}, nil
+}
+
+// Remove deletes asset of the tag.
+func (s *Store) Remove(tag, name string) error {
+	if !isValidName(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	dir, err := s.tagDir(tag)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(filepath.Join(dir, name)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return err
+	}
+
+	return updateChecksums(dir, func(sums map[string]string) { delete(sums, name) })
+}
+
+// Open opens asset of the tag, or its checksums file, for reading.
+func (s *Store) Open(tag, name string) (*os.File, error) {
+	if name != ChecksumsFile && !isValidName(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	dir, err := s.tagDir(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// tagDir returns directory of the tag's assets. Tags are escaped, so tags
+// with slashes, like release/1.0, don't create nested directories.
+func (s *Store) tagDir(tag string) (string, error) {
+	if tag == "" || tag == "." || tag == ".." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return securejoin.SecureJoin(s.dir, url.PathEscape(tag))
+}
+
+func isValidName(name string) bool {
+	return name != "" &&
+		name != ChecksumsFile &&
+		!strings.HasPrefix(name, ".") && // also keeps temp files private
+		!strings.ContainsAny(name, `/\`)
+}
+
+func readChecksums(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, ChecksumsFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sums := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		sum, name, ok := strings.Cut(sc.Text(), "  ")
+		if !ok {
+			continue
+		}
+		sums[name] = sum
+	}
+	return sums, sc.Err()
+}
+
+func updateChecksums(dir string, update func(sums map[string]string)) error {
+	sums, err := readChecksums(dir)
+	if err != nil {
+		return err
+	}
+	update(sums)
+
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(sums)) {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	return os.WriteFile(filepath.Join(dir, ChecksumsFile), []byte(b.String()), 0o644)
+}
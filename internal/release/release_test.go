@@ -0,0 +1,118 @@
+package release
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestStore(t *testing.T) {
+	repoPath := t.TempDir()
+	s := NewStore(repoPath)
+
+	t.Run("no assets", func(t *testing.T) {
+		assets, err := s.Assets("v1.0.0")
+		is.Err(t, err, nil)
+		is.Equal(t, len(assets), 0)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		a, err := s.Add("v1.0.0", "tool-linux.tar.gz", strings.NewReader("linux"))
+		is.Err(t, err, nil)
+		is.Equal(t, a.Size, int64(5))
+		// echo -n linux | sha256sum
+		is.Equal(t, a.SHA256, "caf90169eefa5f807d577486b9f795ab86ae2983c5c20806cff959117e90af18")
+
+		_, err = s.Add("v1.0.0", "tool-darwin.tar.gz", strings.NewReader("darwin"))
+		is.Err(t, err, nil)
+
+		assets, err := s.Assets("v1.0.0")
+		is.Err(t, err, nil)
+		is.Equal(t, len(assets), 2)
+		is.Equal(t, assets[0].Name, "tool-darwin.tar.gz")
+		is.Equal(t, assets[1].Name, "tool-linux.tar.gz")
+		is.Equal(t, assets[1].SHA256, a.SHA256)
+
+		sums, err := os.ReadFile(filepath.Join(repoPath, "mugit-releases", "v1.0.0", ChecksumsFile))
+		is.Err(t, err, nil)
+		is.Equal(t, string(sums), assets[0].SHA256+"  tool-darwin.tar.gz\n"+a.SHA256+"  tool-linux.tar.gz\n")
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		a, err := s.Add("v1.0.0", "tool-darwin.tar.gz", strings.NewReader("darwin arm64"))
+		is.Err(t, err, nil)
+
+		assets, err := s.Assets("v1.0.0")
+		is.Err(t, err, nil)
+		is.Equal(t, len(assets), 2)
+		is.Equal(t, assets[0].SHA256, a.SHA256)
+		is.Equal(t, assets[0].Size, int64(12))
+	})
+
+	t.Run("open", func(t *testing.T) {
+		f, err := s.Open("v1.0.0", "tool-linux.tar.gz")
+		is.Err(t, err, nil)
+		defer func() { _ = f.Close() }()
+
+		data, err := io.ReadAll(f)
+		is.Err(t, err, nil)
+		is.Equal(t, string(data), "linux")
+
+		_, err = s.Open("v1.0.0", "missing.zip")
+		is.Err(t, err, ErrNotFound)
+
+		_, err = s.Open("v2.0.0", "tool-linux.tar.gz")
+		is.Err(t, err, ErrNotFound)
+
+		sums, err := s.Open("v1.0.0", ChecksumsFile)
+		is.Err(t, err, nil)
+		_ = sums.Close()
+	})
+
+	t.Run("tag with slash", func(t *testing.T) {
+		_, err := s.Add("release/2.0", "notes.txt", strings.NewReader("notes"))
+		is.Err(t, err, nil)
+		is.Equal(t, exists(filepath.Join(repoPath, "mugit-releases", "release%2F2.0", "notes.txt")), true)
+
+		assets, err := s.Assets("release/2.0")
+		is.Err(t, err, nil)
+		is.Equal(t, len(assets), 1)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		is.Err(t, s.Remove("v1.0.0", "tool-darwin.tar.gz"), nil)
+		is.Err(t, s.Remove("v1.0.0", "tool-darwin.tar.gz"), ErrNotFound)
+
+		assets, err := s.Assets("v1.0.0")
+		is.Err(t, err, nil)
+		is.Equal(t, len(assets), 1)
+		is.Equal(t, assets[0].Name, "tool-linux.tar.gz")
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, name := range []string{"", ".hidden", "..", "../escape", `a\b`, ChecksumsFile} {
+			_, err := s.Add("v1.0.0", name, strings.NewReader(""))
+			is.Err(t, err, ErrInvalidName)
+		}
+
+		_, err := s.Open("v1.0.0", "../../config")
+		is.Err(t, err, ErrInvalidName)
+	})
+
+	t.Run("invalid tags", func(t *testing.T) {
+		for _, tag := range []string{"", ".", ".."} {
+			_, err := s.Add(tag, "escape.txt", strings.NewReader(""))
+			is.Err(t, err, ErrInvalidTag)
+		}
+		is.Equal(t, exists(filepath.Join(repoPath, "mugit-releases", "escape.txt")), false)
+	})
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
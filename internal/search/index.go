@@ -0,0 +1,229 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/git"
+)
+
+// maxFileSize is max size of file that gets indexed, bigger files are
+// usually generated or data.
+const maxFileSize = 1 << 20
+
+// Index is in-memory trigram index of default branches of all public repos.
+// Repos are reindexed in background, when their HEAD moves.
+type Index struct {
+	c *config.Config
+
+	trigger chan string
+
+	mu    sync.RWMutex
+	repos map[string]*repoIndex
+}
+
+type repoIndex struct {
+	name   string
+	commit string
+	docs   []doc
+
+	// postings maps trigram to sorted ids of docs that contain it
+	postings map[trigram][]uint32
+}
+
+type doc struct {
+	path string
+	blob string
+}
+
+func NewIndex(cfg *config.Config) *Index {
+	return &Index{
+		c:       cfg,
+		trigger: make(chan string, 16),
+		repos:   make(map[string]*repoIndex),
+	}
+}
+
+// Start indexes all repos, and keeps the index up to date until ctx is
+// canceled. Mirrors are reindexed on [Index.Trigger], pushes are only picked
+// up within search.interval, since they're received by `mugit shell`, which
+// runs in its own process.
+func (idx *Index) Start(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case name := <-idx.trigger:
+			if err := idx.update(ctx, name); err != nil {
+				slog.Error("search: failed to index repo", "repo", name, "err", err)
+			}
+
+		case <-timer.C:
+			idx.updateAll(ctx)
+			timer.Reset(idx.c.Search.Interval)
+		}
+	}
+}
+
+// Trigger asks running index to reindex the repo, if it's changed.
+func (idx *Index) Trigger(name string) {
+	select {
+	case idx.trigger <- name:
+	default: // periodic update will pick it up
+	}
+}
+
+func (idx *Index) updateAll(ctx context.Context) {
+	dirs, err := os.ReadDir(idx.c.Repo.Dir)
+	if err != nil {
+		slog.Error("search: failed to list repos", "err", err)
+		return
+	}
+
+	seen := make(map[string]struct{}, len(dirs))
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return
+		}
+		if !dir.IsDir() {
+			continue
+		}
+
+		name := git.ResolveName(dir.Name())
+		seen[name] = struct{}{}
+		if err := idx.update(ctx, name); err != nil {
+			slog.Debug("search: skipping repo", "repo", name, "err", err)
+		}
+	}
+
+	// drop removed repos
+	idx.mu.Lock()
+	for name := range idx.repos {
+		if _, ok := seen[name]; !ok {
+			delete(idx.repos, name)
+		}
+	}
+	idx.mu.Unlock()
+}
+
+// update reindexes the repo if its HEAD has moved. Private and empty repos
+// are removed from the index.
+func (idx *Index) update(ctx context.Context, name string) error {
+	name = git.ResolveName(name)
+	path, err := git.ResolvePath(idx.c.Repo.Dir, name)
+	if err != nil {
+		return err
+	}
+
+	repo, err := git.OpenPublic(path, "")
+	if err != nil || repo.IsEmpty() {
+		idx.remove(name)
+		return err
+	}
+
+	idx.mu.RLock()
+	prev := idx.repos[name]
+	idx.mu.RUnlock()
+	if prev != nil && prev.commit == repo.Hash() {
+		return nil
+	}
+
+	start := time.Now()
+	ri, err := buildRepoIndex(ctx, repo, prev)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.repos[name] = ri
+	idx.mu.Unlock()
+
+	slog.Info("search: indexed repo", "repo", repo.Name(), "files", len(ri.docs), "took", time.Since(start))
+	return nil
+}
+
+func (idx *Index) remove(name string) {
+	idx.mu.Lock()
+	delete(idx.repos, name)
+	idx.mu.Unlock()
+}
+
+// buildRepoIndex indexes files of repo's HEAD. Postings of files that haven't
+// changed since prev are reused, only new and changed files are read.
+func buildRepoIndex(ctx context.Context, repo *git.Repo, prev *repoIndex) (*repoIndex, error) {
+	files, err := repo.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	ri := &repoIndex{
+		name:     repo.Name(),
+		commit:   repo.Hash(),
+		postings: make(map[trigram][]uint32),
+	}
+
+	prevIDs := make(map[doc]uint32)
+	var remap []int64 // id in prev -> id in ri, -1 if file is gone or changed
+	if prev != nil {
+		remap = make([]int64, len(prev.docs))
+		for i, d := range prev.docs {
+			prevIDs[d] = uint32(i)
+			remap[i] = -1
+		}
+	}
+
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if f.Size > maxFileSize {
+			continue
+		}
+
+		d := doc{path: f.Path, blob: f.Hash}
+		id := uint32(len(ri.docs))
+		if prevID, ok := prevIDs[d]; ok {
+			remap[prevID] = int64(id)
+			ri.docs = append(ri.docs, d)
+			continue
+		}
+
+		content, err := repo.Blob(f.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.IndexByte(content, 0) != -1 {
+			continue // binary
+		}
+
+		ri.docs = append(ri.docs, d)
+		for _, t := range trigrams(content) {
+			ri.postings[t] = append(ri.postings[t], id)
+		}
+	}
+
+	if prev != nil {
+		for t, ids := range prev.postings {
+			for _, prevID := range ids {
+				if id := remap[prevID]; id >= 0 {
+					ri.postings[t] = append(ri.postings[t], uint32(id))
+				}
+			}
+		}
+		for _, ids := range ri.postings {
+			slices.Sort(ids)
+		}
+	}
+
+	return ri, nil
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"olexsmir.xyz/mugit/internal/config"
+	"olexsmir.xyz/mugit/internal/git"
+	"olexsmir.xyz/x/is"
+)
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	r, err := gogit.PlainOpen(dir)
+	if err != nil {
+		r, err = gogit.PlainInit(dir, false)
+		is.Err(t, err, nil)
+	}
+
+	fpath := filepath.Join(dir, name)
+	is.Err(t, os.MkdirAll(filepath.Dir(fpath), 0o755), nil)
+	is.Err(t, os.WriteFile(fpath, []byte(content), 0o644), nil)
+
+	wt, err := r.Worktree()
+	is.Err(t, err, nil)
+	_, err = wt.Add(name)
+	is.Err(t, err, nil)
+	_, err = wt.Commit("update "+name, &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@test.local", When: time.Now()},
+	})
+	is.Err(t, err, nil)
+}
+
+func newTestIndex(t *testing.T) (*Index, string) {
+	t.Helper()
+	dir := t.TempDir()
+	return NewIndex(&config.Config{
+		Repo:   config.RepoConfig{Dir: dir},
+		Search: config.SearchConfig{Enable: true, Interval: time.Minute},
+	}), dir
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx, dir := newTestIndex(t)
+	alpha := filepath.Join(dir, "alpha.git")
+	commitFile(t, alpha, "main.go", "package main\n\nfunc main() {\n\tprintln(\"Hello, World\")\n}\n")
+	commitFile(t, alpha, "README.md", "# alpha\nsays hello\n")
+	commitFile(t, alpha, "data.bin", "hello\x00")
+	commitFile(t, filepath.Join(dir, "beta.git"), "lib.go", "package lib\n\n// Hello is a greeting.\nconst Hello = \"hi\"\n")
+	commitFile(t, filepath.Join(dir, "secret.git"), "notes.txt", "hello from private repo\n")
+
+	secret, err := git.Open(filepath.Join(dir, "secret.git"), "")
+	is.Err(t, err, nil)
+	is.Err(t, secret.SetPrivate(true), nil)
+
+	idx.updateAll(t.Context())
+
+	t.Run("literal across repos, private excluded", func(t *testing.T) {
+		res, err := idx.Search(t.Context(), Query{Pattern: "hello"})
+		is.Err(t, err, nil)
+		is.Equal(t, res.Repos, []RepoFacet{{Name: "alpha", Files: 2}, {Name: "beta", Files: 1}})
+		is.Equal(t, len(res.Files), 3)
+		is.Equal(t, res.Matches, 4)
+		is.Equal(t, res.Files[0].Path, "README.md")
+	})
+
+	t.Run("filtered by repo", func(t *testing.T) {
+		res, err := idx.Search(t.Context(), Query{Pattern: "hello", Repo: "beta"})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Repos), 2)
+		is.Equal(t, res.Files, []FileMatch{{Repo: "beta", Path: "lib.go", Matches: []git.GrepMatch{
+			{Line: 3, Content: "// Hello is a greeting."},
+			{Line: 4, Content: "const Hello = \"hi\""},
+		}}})
+	})
+
+	t.Run("regexp, match case", func(t *testing.T) {
+		res, err := idx.Search(t.Context(), Query{Pattern: `^const \w+ =`, Regexp: true, MatchCase: true})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Files), 1)
+		is.Equal(t, res.Files[0].Matches[0].Line, 4)
+	})
+
+	t.Run("invalid regexp", func(t *testing.T) {
+		_, err := idx.Search(t.Context(), Query{Pattern: "(", Regexp: true})
+		is.Err(t, err, ErrInvalidPattern)
+	})
+
+	t.Run("reindexes changed repo", func(t *testing.T) {
+		prev, prevBeta := idx.repos["alpha.git"], idx.repos["beta.git"]
+		commitFile(t, alpha, "README.md", "# alpha\nsays goodbye\n")
+		idx.updateAll(t.Context())
+
+		is.Equal(t, idx.repos["alpha.git"] != prev, true)
+		is.Equal(t, idx.repos["beta.git"] == prevBeta, true)
+
+		res, err := idx.Search(t.Context(), Query{Pattern: "goodbye"})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Files), 1)
+
+		res, err = idx.Search(t.Context(), Query{Pattern: "func main"})
+		is.Err(t, err, nil)
+		is.Equal(t, len(res.Files), 1)
+	})
+
+	t.Run("drops repos that became private", func(t *testing.T) {
+		beta, err := git.Open(filepath.Join(dir, "beta.git"), "")
+		is.Err(t, err, nil)
+		is.Err(t, beta.SetPrivate(true), nil)
+
+		res, err := idx.Search(t.Context(), Query{Pattern: "hello"})
+		is.Err(t, err, nil)
+		is.Equal(t, res.Repos, []RepoFacet{{Name: "alpha", Files: 1}})
+
+		idx.updateAll(t.Context())
+		_, ok := idx.repos["beta.git"]
+		is.Equal(t, ok, false)
+	})
+}
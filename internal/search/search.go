@@ -0,0 +1,190 @@
+package search
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"time"
+
+	"olexsmir.xyz/mugit/internal/git"
+)
+
+const (
+	// MaxMatches is max number of matched lines returned by [Index.Search].
+	MaxMatches = 500
+
+	// searchTimeout bounds time spent on reading and matching candidate files.
+	searchTimeout = 10 * time.Second
+
+	// maxLineLen is max length of matched line, longer lines are cut.
+	maxLineLen = 512
+)
+
+var ErrInvalidPattern = errors.New("invalid search pattern")
+
+type Query struct {
+	Pattern   string
+	Regexp    bool // go regexp syntax, literal string otherwise
+	MatchCase bool
+
+	// Repo limits results to a single repo, facets are still counted for all.
+	Repo string
+}
+
+// RepoFacet is number of matching files in a repo.
+type RepoFacet struct {
+	Name  string
+	Files int
+}
+
+type FileMatch struct {
+	Repo    string
+	Path    string
+	Matches []git.GrepMatch
+}
+
+type Result struct {
+	Repos   []RepoFacet
+	Files   []FileMatch
+	Matches int
+
+	// Truncated is set when search hit max matches, or timed out, in which
+	// case facets are incomplete too.
+	Truncated bool
+	TimedOut  bool
+}
+
+// Search finds lines matching the query in all indexed repos. Repos that
+// became private after being indexed are skipped.
+func (idx *Index) Search(ctx context.Context, q Query) (*Result, error) {
+	res := &Result{}
+	if q.Pattern == "" {
+		return res, nil
+	}
+
+	expr := q.Pattern
+	if !q.Regexp {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if !q.MatchCase {
+		expr = "(?i)" + expr
+	}
+	// whole files are matched first, ^ and $ should still match lines
+	expr = "(?m)" + expr
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+	tq := regexpQuery(parsed.Simplify())
+
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	q.Repo = strings.TrimSuffix(q.Repo, ".git")
+	for _, ri := range idx.snapshot() {
+		ids := tq.eval(ri.postings)
+		if ids == nil {
+			ids = make([]uint32, len(ri.docs))
+			for i := range ids {
+				ids[i] = uint32(i)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		repo, err := git.OpenPublic(ri.path(idx.c.Repo.Dir), "")
+		if err != nil {
+			continue
+		}
+
+		facet := RepoFacet{Name: ri.name}
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				res.Truncated, res.TimedOut = true, true
+				break
+			}
+			if res.Matches >= MaxMatches {
+				res.Truncated = true
+				break
+			}
+
+			d := ri.docs[id]
+			content, err := repo.Blob(d.blob)
+			if err != nil || !re.Match(content) {
+				continue
+			}
+
+			facet.Files++
+			if q.Repo != "" && q.Repo != ri.name {
+				continue
+			}
+
+			fm := FileMatch{Repo: ri.name, Path: d.path, Matches: matchLines(re, content, MaxMatches-res.Matches)}
+			if len(fm.Matches) == 0 {
+				continue // matched across lines
+			}
+			res.Matches += len(fm.Matches)
+			res.Files = append(res.Files, fm)
+		}
+
+		if facet.Files > 0 {
+			res.Repos = append(res.Repos, facet)
+		}
+		if res.Truncated {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// snapshot returns indexed repos sorted by name. Repo indexes are
+// never modified after they are built, so it's safe to use them unlocked.
+func (idx *Index) snapshot() []*repoIndex {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	out := make([]*repoIndex, 0, len(idx.repos))
+	for _, ri := range idx.repos {
+		out = append(out, ri)
+	}
+	slices.SortFunc(out, func(a, b *repoIndex) int { return cmp.Compare(a.name, b.name) })
+	return out
+}
+
+func (ri *repoIndex) path(dir string) string {
+	path, _ := git.ResolvePath(dir, git.ResolveName(ri.name))
+	return path
+}
+
+func matchLines(re *regexp.Regexp, content []byte, limit int) []git.GrepMatch {
+	var out []git.GrepMatch
+	n := 0
+	for line := range bytes.Lines(content) {
+		n++
+		line = bytes.TrimRight(line, "\r\n")
+		if !re.Match(line) {
+			continue
+		}
+		if len(line) > maxLineLen {
+			line = line[:maxLineLen]
+		}
+		out = append(out, git.GrepMatch{Line: n, Content: strings.ToValidUTF8(string(line), "")})
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
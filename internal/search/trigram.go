@@ -0,0 +1,230 @@
+package search
+
+import (
+	"regexp/syntax"
+	"slices"
+)
+
+type trigram uint32
+
+func newTrigram(a, b, c byte) trigram {
+	return trigram(lower(a))<<16 | trigram(lower(b))<<8 | trigram(lower(c))
+}
+
+// lower folds only ascii, so index and queries agree on bytes of
+// multi-byte characters.
+func lower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// trigrams returns sorted, unique, case folded trigrams of content.
+func trigrams(content []byte) []trigram {
+	if len(content) < 3 {
+		return nil
+	}
+
+	seen := make(map[trigram]struct{}, len(content)/4)
+	for i := 0; i+2 < len(content); i++ {
+		seen[newTrigram(content[i], content[i+1], content[i+2])] = struct{}{}
+	}
+
+	out := make([]trigram, 0, len(seen))
+	for t := range seen {
+		out = append(out, t)
+	}
+	slices.Sort(out)
+	return out
+}
+
+type queryOp int
+
+const (
+	opAll queryOp = iota // matches every document
+	opAnd
+	opOr
+	opTrigram
+)
+
+// query is a boolean query over trigrams, that every document matching
+// a regexp also matches. It narrows down documents that regexp is run on.
+type query struct {
+	op  queryOp
+	tri trigram
+	sub []*query
+}
+
+var matchAll = &query{op: opAll}
+
+// regexpQuery builds trigram query from a regexp. It only considers literal
+// strings that must be present in a match, other parts of the regexp match all.
+func regexpQuery(re *syntax.Regexp) *query {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return literalQuery(string(re.Rune), re.Flags&syntax.FoldCase != 0)
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexpQuery(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min == 0 {
+			return matchAll
+		}
+		return regexpQuery(re.Sub[0])
+
+	case syntax.OpConcat:
+		var subs []*query
+		// adjacent literals are joined, so trigrams crossing them are used
+		var lit []rune
+		var litFold bool
+		flush := func() {
+			if len(lit) > 0 {
+				subs = append(subs, literalQuery(string(lit), litFold))
+				lit = nil
+			}
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				fold := sub.Flags&syntax.FoldCase != 0
+				if len(lit) > 0 && fold != litFold {
+					flush()
+				}
+				lit, litFold = append(lit, sub.Rune...), fold
+				continue
+			}
+			flush()
+			subs = append(subs, regexpQuery(sub))
+		}
+		flush()
+		return and(subs...)
+
+	case syntax.OpAlternate:
+		subs := make([]*query, len(re.Sub))
+		for i, sub := range re.Sub {
+			subs[i] = regexpQuery(sub)
+		}
+		return or(subs...)
+	}
+
+	return matchAll
+}
+
+func literalQuery(s string, fold bool) *query {
+	var subs []*query
+	for i := 0; i+2 < len(s); i++ {
+		// case folding of non-ascii characters can't be expressed in bytes
+		if fold && (s[i] >= 0x80 || s[i+1] >= 0x80 || s[i+2] >= 0x80) {
+			continue
+		}
+		subs = append(subs, &query{op: opTrigram, tri: newTrigram(s[i], s[i+1], s[i+2])})
+	}
+	return and(subs...)
+}
+
+func and(subs ...*query) *query {
+	var out []*query
+	for _, q := range subs {
+		if q.op != opAll {
+			out = append(out, q)
+		}
+	}
+	switch len(out) {
+	case 0:
+		return matchAll
+	case 1:
+		return out[0]
+	}
+	return &query{op: opAnd, sub: out}
+}
+
+func or(subs ...*query) *query {
+	if len(subs) == 0 || slices.ContainsFunc(subs, func(q *query) bool { return q.op == opAll }) {
+		return matchAll
+	}
+	if len(subs) == 1 {
+		return subs[0]
+	}
+	return &query{op: opOr, sub: subs}
+}
+
+// eval returns sorted ids of documents matching the query, postings are
+// sorted ids of documents that contain the trigram. Nil means all documents.
+func (q *query) eval(postings map[trigram][]uint32) []uint32 {
+	switch q.op {
+	case opTrigram:
+		if ids, ok := postings[q.tri]; ok {
+			return ids
+		}
+		return []uint32{}
+
+	case opAnd:
+		var out []uint32
+		for _, sub := range q.sub {
+			ids := sub.eval(postings)
+			if ids == nil {
+				continue
+			}
+			if out == nil {
+				out = ids
+			} else {
+				out = intersect(out, ids)
+			}
+			if len(out) == 0 {
+				return []uint32{}
+			}
+		}
+		return out
+
+	case opOr:
+		var out []uint32
+		for _, sub := range q.sub {
+			ids := sub.eval(postings)
+			if ids == nil {
+				return nil
+			}
+			out = union(out, ids)
+		}
+		return out
+	}
+	return nil
+}
+
+func intersect(a, b []uint32) []uint32 {
+	out := make([]uint32, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func union(a, b []uint32) []uint32 {
+	out := make([]uint32, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			out = append(out, a[i])
+			i++
+		case a[i] > b[j]:
+			out = append(out, b[j])
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	out = append(out, a[i:]...)
+	return append(out, b[j:]...)
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"regexp/syntax"
+	"testing"
+
+	"olexsmir.xyz/x/is"
+)
+
+func TestTrigrams(t *testing.T) {
+	is.Equal(t, trigrams([]byte("ab")), []trigram(nil))
+	is.Equal(t, trigrams([]byte("AbCabc")), []trigram{
+		newTrigram('a', 'b', 'c'),
+		newTrigram('b', 'c', 'a'),
+		newTrigram('c', 'a', 'b'),
+	})
+}
+
+func TestRegexpQuery(t *testing.T) {
+	// docs: 0 "hello world", 1 "help", 2 "world peace"
+	postings := make(map[trigram][]uint32)
+	for id, content := range []string{"hello world", "help", "world peace"} {
+		for _, tri := range trigrams([]byte(content)) {
+			postings[tri] = append(postings[tri], uint32(id))
+		}
+	}
+
+	tests := []struct {
+		expr string
+		want []uint32
+	}{
+		{expr: "hello", want: []uint32{0}},
+		{expr: "(?i)HEL", want: []uint32{0, 1}},
+		{expr: "hel(lo|p)", want: []uint32{0, 1}},
+		{expr: "world|peace", want: []uint32{0, 2}},
+		{expr: "hello.*peace", want: []uint32{}},
+		{expr: "worl+d", want: []uint32{0, 2}},
+		{expr: "wor+ld", want: nil},
+		{expr: "nothing", want: []uint32{}},
+		{expr: "x*", want: nil},
+		{expr: "[a-z]+", want: nil},
+		{expr: "he|.", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			re, err := syntax.Parse(tt.expr, syntax.Perl)
+			is.Err(t, err, nil)
+			is.Equal(t, regexpQuery(re.Simplify()).eval(postings), tt.want)
+		})
+	}
+}
@@ -73,12 +73,20 @@ func (s *Shell) HandleCommand(ctx context.Context, cmd string, stdin io.Reader,
 		}
 	}
 
+	if gitCmd == "git-receive-pack" {
+		if isMirror, _ := repo.IsMirror(); isMirror {
+			msg := "repository is a mirror, pushes are not accepted"
+			return s.replyWithGitError(stderr, msg, errors.New(msg))
+		}
+	}
+
 	if s.cfg.Meta.Modt != "" {
 		_, _ = fmt.Fprintln(stderr, s.cfg.Meta.Modt)
 	}
 
 	switch gitCmd {
 	case "git-upload-pack":
+		repo.HideRefs(s.cfg.Repo.HideRefs)
 		err = repo.UploadPack(ctx, false, "", stdin, stdout)
 	case "git-upload-archive":
 		err = repo.UploadArchive(ctx, stdin, stdout)
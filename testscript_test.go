@@ -92,7 +92,7 @@ func testMain(m *testing.M) int {
 
 	httpServer := &http.Server{
 		Addr:    net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler: handlers.InitRoutes(cfg),
+		Handler: handlers.InitRoutes(cfg, nil),
 	}
 	go func() {
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {